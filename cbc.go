@@ -0,0 +1,203 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"runtime"
+	"sync"
+)
+
+// smallest input, in bytes, that CBC decryption splits across goroutines
+const cbcParallelThreshold = 64 * 1024
+
+// blocksDecrypter is implemented by ciphers that decrypt several blocks
+// faster than one Decrypt call per block.
+type blocksDecrypter interface {
+	decryptBlocks(dst, src []byte)
+}
+
+type cbc struct {
+	b 				cipher.Block 	// block cipher
+	blockSize 		int 			// block size in bytes
+	iv 				[]byte 			// chaining value
+	tmp 			[]byte 			// scratch block
+}
+
+func newCBC(b cipher.Block, iv []byte) *cbc {
+	c := cbc{
+		b: b,
+		blockSize: b.BlockSize(),
+		iv: make([]byte, len(iv)),
+		tmp: make([]byte, len(iv)),
+	}
+	copy(c.iv, iv)
+	return &c
+}
+
+type cbcEncrypter cbc
+
+// NewCBCEncrypter returns a BlockMode which encrypts in cipher block chaining
+// mode, using the given Block, as RC5-CBC does in RFC 2040 section 7. The
+// length of iv must be the same as the Block's block size.
+func NewCBCEncrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != b.BlockSize() {
+		panic("rc5.NewCBCEncrypter: IV length must equal block size")
+	}
+	return (*cbcEncrypter)(newCBC(b, iv))
+}
+
+func (x *cbcEncrypter) BlockSize() int { return x.blockSize }
+
+func (x *cbcEncrypter) CryptBlocks(dst, src []byte) {
+	if len(src) % x.blockSize != 0 {
+		panic("rc5: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	iv := x.iv
+	for len(src) > 0 {
+		xorBytes(dst[:x.blockSize], src, iv)
+		x.b.Encrypt(dst, dst[:x.blockSize])
+		iv = dst[:x.blockSize]
+		dst, src = dst[x.blockSize:], src[x.blockSize:]
+	}
+	copy(x.iv, iv)
+}
+
+func (x *cbcEncrypter) SetIV(iv []byte) {
+	if len(iv) != len(x.iv) {
+		panic("rc5: incorrect length IV")
+	}
+	copy(x.iv, iv)
+}
+
+type cbcDecrypter struct {
+	*cbc
+	workers 		int 			// maximum number of goroutines
+}
+
+// NewCBCDecrypter returns a BlockMode which decrypts in cipher block chaining
+// mode, using the given Block. The length of iv must be the same as the
+// Block's block size. Inputs of at least 64 KiB are decrypted in parallel.
+func NewCBCDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != b.BlockSize() {
+		panic("rc5.NewCBCDecrypter: IV length must equal block size")
+	}
+	return &cbcDecrypter{newCBC(b, iv), runtime.GOMAXPROCS(0)}
+}
+
+func (x *cbcDecrypter) BlockSize() int { return x.blockSize }
+
+func (x *cbcDecrypter) CryptBlocks(dst, src []byte) {
+	if len(src) % x.blockSize != 0 {
+		panic("rc5: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+	if len(src) == 0 {
+		return
+	}
+
+	bs := x.blockSize
+	blocks := len(src) / bs
+
+	// the last ciphertext block chains into the next call
+	copy(x.tmp, src[len(src) - bs:])
+
+	workers := x.workers
+	if len(src) < cbcParallelThreshold {
+		workers = 1
+	}
+	if workers > blocks {
+		workers = blocks
+	}
+
+	if workers <= 1 {
+		cbcDecryptChunk(x.b, x.iv, dst, src)
+	} else {
+		share := (blocks + workers - 1) / workers
+
+		// each chunk chains from the ciphertext block before it, which
+		// an in-place call would overwrite, so take copies first
+		var prevs [][]byte
+		for lo := 0; lo < blocks; lo += share {
+			prev := make([]byte, bs)
+			if lo == 0 {
+				copy(prev, x.iv)
+			} else {
+				copy(prev, src[(lo - 1) * bs:lo * bs])
+			}
+			prevs = append(prevs, prev)
+		}
+
+		var wg sync.WaitGroup
+		for n, lo := 0, 0; lo < blocks; n, lo = n + 1, lo + share {
+			hi := lo + share
+			if hi > blocks {
+				hi = blocks
+			}
+			wg.Add(1)
+			go func(prev, dst, src []byte) {
+				defer wg.Done()
+				cbcDecryptChunk(x.b, prev, dst, src)
+			}(prevs[n], dst[lo * bs:hi * bs], src[lo * bs:hi * bs])
+		}
+		wg.Wait()
+	}
+
+	x.iv, x.tmp = x.tmp, x.iv
+}
+
+func (x *cbcDecrypter) SetIV(iv []byte) {
+	if len(iv) != len(x.iv) {
+		panic("rc5: incorrect length IV")
+	}
+	copy(x.iv, iv)
+}
+
+// cbcDecryptChunk decrypts src, chained from the ciphertext block prev, into
+// dst. Groups of blocks are decrypted together from the end of src backwards,
+// so dst may overlap src entirely.
+func cbcDecryptChunk(b cipher.Block, prev []byte, dst, src []byte) {
+	bs := len(prev)
+	group := lanes * bs
+	tmp := make([]byte, group)
+
+	for end := len(src); end > 0; {
+		start := end - group
+		if start < 0 {
+			start = 0
+		}
+
+		decryptBlocks(b, tmp[:end - start], src[start:end])
+		for i := end - bs; i >= start; i -= bs {
+			chain := prev
+			if i > 0 {
+				chain = src[i - bs:i]
+			}
+			xorBytes(dst[i:i + bs], tmp[i - start:i - start + bs], chain)
+		}
+
+		end = start
+	}
+}
+
+// decryptBlocks decrypts a whole number of blocks with b.
+func decryptBlocks(b cipher.Block, dst, src []byte) {
+	if d, ok := b.(blocksDecrypter); ok {
+		d.decryptBlocks(dst, src)
+		return
+	}
+
+	bs := b.BlockSize()
+	for len(src) > 0 {
+		b.Decrypt(dst, src)
+		dst, src = dst[bs:], src[bs:]
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+)
+
+type cbcHMAC struct {
+	b 				cipher.Block 		// RC5 cipher keyed by ENC_KEY
+	hashFunc 		func() hash.Hash 	// hash for HMAC
+	macKey 			[]byte 				// MAC_KEY
+	tagSize 		int 				// T_LEN in bytes
+}
+
+// NewCBCHMAC returns the encrypt-then-MAC composition of RFC 7518 section
+// 5.2, AES_CBC_HMAC_SHA2, with RC5-CBC in place of AES-CBC: RC5-CBC-Pad
+// encryption under encKey with the RC5 variant params, then HMAC over the
+// additional data, IV, ciphertext and the additional data's length in bits,
+// keyed by macKey. The nonce is the CBC IV, one block, and must be
+// unpredictable. Tags are half the hash size, as in RFC 7518.
+func NewCBCHMAC(encKey, macKey []byte, params Params, hashFunc func() hash.Hash) (cipher.AEAD, error) {
+	return NewCBCHMACWithTagSize(encKey, macKey, params, hashFunc, hashFunc().Size() / 2)
+}
+
+// NewCBCHMACWithTagSize is NewCBCHMAC with tags truncated to tagSize bytes,
+// from 1 to the hash size.
+func NewCBCHMACWithTagSize(encKey, macKey []byte, params Params, hashFunc func() hash.Hash, tagSize int) (cipher.AEAD, error) {
+	if tagSize < 1 || tagSize > hashFunc().Size() {
+		return nil, TagSizeError(tagSize)
+	}
+
+	b, err := NewCipher(encKey, params.R, params.W)
+	if err != nil {
+		return nil, err
+	}
+	if b.BlockSize() > 255 {
+		return nil, BlockSizeError(b.BlockSize())
+	}
+
+	key := make([]byte, len(macKey))
+	copy(key, macKey)
+	return &cbcHMAC{b, hashFunc, key, tagSize}, nil
+}
+
+func (c *cbcHMAC) NonceSize() int { return c.b.BlockSize() }
+
+// Overhead is the most the ciphertext can exceed the plaintext by: a block
+// of padding and the tag.
+func (c *cbcHMAC) Overhead() int { return c.b.BlockSize() + c.tagSize }
+
+func (c *cbcHMAC) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	bs := c.b.BlockSize()
+	if len(nonce) != bs {
+		panic("rc5: incorrect nonce length given to CBC-HMAC")
+	}
+
+	// the plaintext is copied first, so it may overlap dst
+	padded := len(plaintext) / bs * bs + bs
+	ret, out := sliceForAppend(dst, padded + c.tagSize)
+	e := NewCBCPadEncrypter(c.b, nonce)
+	n := e.Update(out, append([]byte(nil), plaintext...))
+	e.Final(out[n:])
+
+	tag := c.tag(nonce, out[:padded], additionalData)
+	copy(out[padded:], tag[:c.tagSize])
+	return ret
+}
+
+func (c *cbcHMAC) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	bs := c.b.BlockSize()
+	if len(nonce) != bs {
+		panic("rc5: incorrect nonce length given to CBC-HMAC")
+	}
+
+	n := len(ciphertext) - c.tagSize
+	if n < bs || n % bs != 0 {
+		return nil, AuthenticationError{}
+	}
+
+	// nothing is decrypted until the tag checks out
+	tag := c.tag(nonce, ciphertext[:n], additionalData)
+	if subtle.ConstantTimeCompare(tag[:c.tagSize], ciphertext[n:]) != 1 {
+		return nil, AuthenticationError{}
+	}
+
+	plain := make([]byte, n)
+	d := NewCBCPadDecrypter(c.b, nonce)
+	m := d.Update(plain, ciphertext[:n])
+	f, err := d.Final(plain[m:])
+	if err != nil {
+		return nil, AuthenticationError{}
+	}
+
+	ret, out := sliceForAppend(dst, m + f)
+	copy(out, plain)
+	return ret, nil
+}
+
+// tag is HMAC(MAC_KEY, A || IV || E || AL).
+func (c *cbcHMAC) tag(iv, ciphertext, additionalData []byte) []byte {
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(additionalData)) * 8)
+
+	mac := hmac.New(c.hashFunc, c.macKey)
+	mac.Write(additionalData)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(al[:])
+	return mac.Sum(nil)
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/rand"
+	"testing"
+)
+
+func TestCBCHMAC(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	encKey := make([]byte, 16)
+	macKey := make([]byte, 32)
+	random.Read(encKey)
+	random.Read(macKey)
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		for _, tagSize := range []int{8, 32, 64} {
+			aead, err := NewCBCHMACWithTagSize(encKey, macKey, Params{wordSize, 12}, sha512.New, tagSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block, _ := NewCipher(encKey, 12, wordSize)
+			bs := block.BlockSize()
+
+			nonce := make([]byte, aead.NonceSize())
+			random.Read(nonce)
+			plain := make([]byte, 3 * bs + 1)
+			random.Read(plain)
+			data := make([]byte, 7)
+			random.Read(data)
+
+			sealed := aead.Seal(nil, nonce, plain, data)
+			if len(sealed) != 4 * bs + tagSize {
+				t.Errorf("CBC-HMAC(%d, %d byte tags) sealed %d bytes, want %d", wordSize, tagSize, len(sealed), 4 * bs + tagSize)
+			}
+
+			// the ciphertext is RC5-CBC over the PKCS #7 padded plaintext and
+			// the tag a truncated HMAC
+			n := bs - len(plain) % bs
+			ciphertext := append(append([]byte(nil), plain...), bytes.Repeat([]byte{byte(n)}, n)...)
+			cipher.NewCBCEncrypter(block, nonce).CryptBlocks(ciphertext, ciphertext)
+			mac := hmac.New(sha512.New, macKey)
+			mac.Write(data)
+			mac.Write(nonce)
+			mac.Write(ciphertext)
+			mac.Write([]byte{0, 0, 0, 0, 0, 0, 0, 56})
+			expected := append(ciphertext, mac.Sum(nil)[:tagSize]...)
+			if !bytes.Equal(sealed, expected) {
+				t.Errorf("CBC-HMAC(%d, %d byte tags) == % 02x, want % 02x", wordSize, tagSize, sealed, expected)
+			}
+
+			opened, err := aead.Open(nil, nonce, sealed, data)
+			if err != nil || !bytes.Equal(opened, plain) {
+				t.Errorf("CBC-HMAC(%d, %d byte tags) round trip failed: %v", wordSize, tagSize, err)
+			}
+
+			for _, i := range []int{0, len(sealed) - tagSize - 1, len(sealed) - 1} {
+				sealed[i] ^= 1
+				if _, err := aead.Open(nil, nonce, sealed, data); err != (AuthenticationError{}) {
+					t.Errorf("CBC-HMAC(%d, %d byte tags) opened with byte %d modified: %v", wordSize, tagSize, i, err)
+				}
+				sealed[i] ^= 1
+			}
+			if _, err := aead.Open(nil, nonce, sealed, data[1:]); err != (AuthenticationError{}) {
+				t.Errorf("CBC-HMAC(%d, %d byte tags) opened with other additional data: %v", wordSize, tagSize, err)
+			}
+			if _, err := aead.Open(nil, nonce, sealed[bs:], data); err != (AuthenticationError{}) {
+				t.Errorf("CBC-HMAC(%d, %d byte tags) opened a truncated ciphertext: %v", wordSize, tagSize, err)
+			}
+		}
+	}
+
+	// by default tags are half the hash size
+	aead, _ := NewCBCHMAC(encKey, macKey, Params{32, 12}, sha256.New)
+	truncated, _ := NewCBCHMACWithTagSize(encKey, macKey, Params{32, 12}, sha256.New, 16)
+	nonce := make([]byte, aead.NonceSize())
+	if aead.Overhead() != 8 + 16 || !bytes.Equal(aead.Seal(nil, nonce, encKey, macKey), truncated.Seal(nil, nonce, encKey, macKey)) {
+		t.Error("NewCBCHMAC with SHA-256 does not give 16 byte tags")
+	}
+
+	if _, err := NewCBCHMACWithTagSize(encKey, macKey, Params{32, 12}, sha256.New, 33); err != TagSizeError(33) {
+		t.Errorf("NewCBCHMACWithTagSize(33) error == %v, want TagSizeError", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// ISO9797Padding is a padding method of ISO/IEC 9797-1.
+type ISO9797Padding int
+
+const (
+	// ISO9797Padding1 appends the fewest zero bytes, or a zero block to
+	// empty data.
+	ISO9797Padding1 ISO9797Padding = 1 + iota
+	// ISO9797Padding2 appends 0x80 then the fewest zero bytes.
+	ISO9797Padding2
+	// ISO9797Padding3 prepends a block holding the length of the data in
+	// bits, then pads as method 1 but adds nothing to empty data.
+	ISO9797Padding3
+)
+
+// CBCMAC is MAC algorithm 1 or 3 of ISO/IEC 9797-1: the CBC-MAC of the
+// padded data, through one more decryption and encryption with a second key
+// for algorithm 3, the retail MAC of ANSI X9.19. It implements hash.Hash;
+// Sum appends a MAC of Size bytes.
+type CBCMAC struct {
+	b 				cipher.Block 	// block cipher, K
+	final 			cipher.Block 	// second key K' for algorithm 3, or nil
+	padding 		ISO9797Padding 	// padding method
+	macSize 		int 			// MAC size in bytes
+	mac 			[]byte 			// CBC-MAC of the blocks before buf
+	buf 			[]byte 			// last partial block
+	n 				int 			// bytes in buf
+	length 			uint64 			// bytes written
+	msg 			[]byte 			// whole message, for padding method 3
+}
+
+// NewCBCMAC returns an ISO/IEC 9797-1 CBC-MAC keyed by b, with MAC
+// algorithm 1 when final is nil and algorithm 3 otherwise, where final is
+// the second key and must have b's block size. MACs are truncated to
+// macSize bytes, at most one block. Padding method 3 needs the length of
+// the data first, so it keeps everything written until Sum.
+func NewCBCMAC(b, final cipher.Block, padding ISO9797Padding, macSize int) (*CBCMAC, error) {
+	bs := b.BlockSize()
+	if final != nil && final.BlockSize() != bs {
+		return nil, BlockSizeError(final.BlockSize())
+	}
+	if padding < ISO9797Padding1 || padding > ISO9797Padding3 {
+		return nil, PaddingMethodError(padding)
+	}
+	if macSize < 1 || macSize > bs {
+		return nil, TagSizeError(macSize)
+	}
+
+	return &CBCMAC{
+		b: b,
+		final: final,
+		padding: padding,
+		macSize: macSize,
+		mac: make([]byte, bs),
+		buf: make([]byte, bs),
+	}, nil
+}
+
+func (m *CBCMAC) Size() int { return m.macSize }
+
+func (m *CBCMAC) BlockSize() int { return len(m.buf) }
+
+func (m *CBCMAC) Reset() {
+	for i := range m.mac {
+		m.mac[i] = 0
+	}
+	m.n = 0
+	m.length = 0
+	m.msg = m.msg[:0]
+}
+
+func (m *CBCMAC) Write(p []byte) (int, error) {
+	m.length += uint64(len(p))
+	if m.padding == ISO9797Padding3 {
+		m.msg = append(m.msg, p...)
+	} else {
+		m.update(p)
+	}
+	return len(p), nil
+}
+
+// update chains p into the CBC-MAC, keeping back any partial block.
+func (m *CBCMAC) update(p []byte) {
+	bs := len(m.buf)
+	for len(p) > 0 {
+		n := copy(m.buf[m.n:], p)
+		m.n += n
+		p = p[n:]
+
+		if m.n == bs {
+			xorBytes(m.mac, m.mac, m.buf)
+			m.b.Encrypt(m.mac, m.mac)
+			m.n = 0
+		}
+	}
+}
+
+// Sum appends the MAC of the data written so far to in. It does not change
+// the underlying state.
+func (m *CBCMAC) Sum(in []byte) []byte {
+	bs := len(m.buf)
+	x := *m
+	x.mac = make([]byte, bs)
+	x.buf = make([]byte, bs)
+	copy(x.mac, m.mac)
+	copy(x.buf, m.buf)
+
+	if m.padding == ISO9797Padding3 {
+		bits := make([]byte, bs)
+		for i, l := bs - 1, m.length << 3; i >= 0 && l > 0; i, l = i - 1, l >> 8 {
+			bits[i] = byte(l)
+		}
+		x.update(bits)
+		x.update(m.msg)
+	}
+
+	pad := x.n > 0
+	switch m.padding {
+	case ISO9797Padding1:
+		pad = pad || m.length == 0
+	case ISO9797Padding2:
+		x.buf[x.n] = 0x80
+		x.n++
+		pad = true
+	}
+	if pad {
+		for i := x.n; i < bs; i++ {
+			x.buf[i] = 0
+		}
+		x.n = 0
+		xorBytes(x.mac, x.mac, x.buf)
+		m.b.Encrypt(x.mac, x.mac)
+	}
+
+	if m.final != nil {
+		m.final.Decrypt(x.mac, x.mac)
+		m.b.Encrypt(x.mac, x.mac)
+	}
+	return append(in, x.mac[:m.macSize]...)
+}
+
+// Verify reports, in constant time, whether mac is the MAC of the data
+// written so far.
+func (m *CBCMAC) Verify(mac []byte) bool {
+	return subtle.ConstantTimeCompare(m.Sum(nil), mac) == 1
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/hex"
+	"hash"
+	"math/rand"
+	"testing"
+)
+
+var _ hash.Hash = (*CBCMAC)(nil)
+
+// DES MACs over the keys and data of ISO/IEC 9797-1 annex B, checking the
+// algorithms and padding methods themselves.
+var cbcmacVectors = []struct {
+	algorithm 		int
+	padding 		ISO9797Padding
+	data 			string
+	mac 			string
+}{
+	{1, ISO9797Padding1, "Now is the time for all ", "70a30640cc76dd8b"},
+	{1, ISO9797Padding2, "Now is the time for all ", "10e1f0f108341b6d"},
+	{1, ISO9797Padding3, "Now is the time for all ", "2c58fb8ff12aaeac"},
+	{3, ISO9797Padding1, "Now is the time for all ", "a1c72e74ea3fa9b6"},
+	{3, ISO9797Padding2, "Now is the time for all ", "e9086230ca3be796"},
+	{3, ISO9797Padding3, "Now is the time for all ", "ab059463d7a7d170"},
+	{1, ISO9797Padding1, "Now is the time for it", "e45b3ad2b7cc0856"},
+	{1, ISO9797Padding2, "Now is the time for it", "a924c72136149211"},
+	{1, ISO9797Padding3, "Now is the time for it", "b1ecd6fc8b37c392"},
+	{3, ISO9797Padding1, "Now is the time for it", "2e2b1428cc78254f"},
+	{3, ISO9797Padding2, "Now is the time for it", "5a692ce64f404145"},
+	{3, ISO9797Padding3, "Now is the time for it", "c59f7eed328ddd69"},
+}
+
+func TestCBCMACVectors(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789abcdef")
+	key2, _ := hex.DecodeString("fedcba9876543210")
+	block, _ := des.NewCipher(key)
+	final, _ := des.NewCipher(key2)
+
+	for _, v := range cbcmacVectors {
+		var second cipher.Block
+		if v.algorithm == 3 {
+			second = final
+		}
+		expected, _ := hex.DecodeString(v.mac)
+
+		mac, _ := NewCBCMAC(block, second, v.padding, 8)
+		mac.Write([]byte(v.data))
+		if sum := mac.Sum(nil); !bytes.Equal(sum, expected) {
+			t.Errorf("algorithm %d, padding %d, %q: MAC == % 02x, want % 02x", v.algorithm, v.padding, v.data, sum, expected)
+		}
+	}
+}
+
+// padISO9797 pads msg as the given method does for blocks of bs bytes.
+func padISO9797(msg []byte, padding ISO9797Padding, bs int) []byte {
+	var padded []byte
+	if padding == ISO9797Padding3 {
+		padded = make([]byte, bs)
+		bits := uint64(len(msg)) * 8
+		for i := bs - 1; i >= 0 && bits > 0; i-- {
+			padded[i] = byte(bits)
+			bits >>= 8
+		}
+	}
+	padded = append(padded, msg...)
+
+	switch {
+	case padding == ISO9797Padding2:
+		padded = append(padded, 0x80)
+	case padding == ISO9797Padding1 && len(msg) == 0:
+		padded = append(padded, make([]byte, bs)...)
+	}
+	for len(padded) % bs != 0 {
+		padded = append(padded, 0)
+	}
+	return padded
+}
+
+func TestCBCMAC(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 32)
+		random.Read(key)
+		block, _ := NewCipher(key[:16], 12, wordSize)
+		final, _ := NewCipher(key[16:], 12, wordSize)
+		bs := block.BlockSize()
+
+		for padding := ISO9797Padding1; padding <= ISO9797Padding3; padding++ {
+			for _, size := range []int{0, 1, bs, 3 * bs + 1} {
+				msg := make([]byte, size)
+				random.Read(msg)
+
+				// the last block of plain CBC encryption from a zero IV
+				padded := padISO9797(msg, padding, bs)
+				NewCBCEncrypter(block, make([]byte, bs)).CryptBlocks(padded, padded)
+				expected1 := padded[len(padded) - bs:]
+				expected3 := make([]byte, bs)
+				final.Decrypt(expected3, expected1)
+				block.Encrypt(expected3, expected3)
+
+				for _, v := range []struct {
+					final 			cipher.Block
+					expected 		[]byte
+				}{
+					{nil, expected1},
+					{final, expected3},
+				} {
+					mac, _ := NewCBCMAC(block, v.final, padding, bs)
+					for n := 0; n < size; {
+						m := n + random.Intn(bs + 2)
+						if m > size {
+							m = size
+						}
+						mac.Write(msg[n:m])
+						mac.Sum(nil)
+						n = m
+					}
+
+					if sum := mac.Sum(nil); !bytes.Equal(sum, v.expected) {
+						t.Errorf("CBCMAC(%d, padding %d, %d bytes) == % 02x, want % 02x", wordSize, padding, size, sum, v.expected)
+					}
+
+					short, _ := NewCBCMAC(block, v.final, padding, bs / 2)
+					short.Write(msg)
+					if !short.Verify(v.expected[:bs / 2]) || short.Verify(v.expected) {
+						t.Errorf("CBCMAC(%d, padding %d, %d bytes) truncated MAC failed to verify", wordSize, padding, size)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCBCMACErrors(t *testing.T) {
+	block, _ := NewCipher32(make([]byte, 16), 12)
+	wide, _ := NewCipher64(make([]byte, 16), 12)
+
+	if _, err := NewCBCMAC(block, wide, ISO9797Padding1, 8); err != BlockSizeError(16) {
+		t.Errorf("NewCBCMAC with mismatched keys error == %v, want BlockSizeError", err)
+	}
+	if _, err := NewCBCMAC(block, nil, 4, 8); err != PaddingMethodError(4) {
+		t.Errorf("NewCBCMAC(padding 4) error == %v, want PaddingMethodError", err)
+	}
+	if _, err := NewCBCMAC(block, nil, ISO9797Padding2, 9); err != TagSizeError(9) {
+		t.Errorf("NewCBCMAC(macSize 9) error == %v, want TagSizeError", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// CBCPadEncrypter is the RC5-CBC-Pad encryption object of RFC 2040 section 8.
+// Update may be called any number of times with input of any length; Final
+// then pads the remainder to a whole block, as RC5_CBC_Encrypt_Final does.
+type CBCPadEncrypter struct {
+	mode 			cipher.BlockMode 	// RC5-CBC
+	buf 			[]byte 				// unprocessed input
+	n 				int 				// number of bytes in buf
+}
+
+// NewCBCPadEncrypter returns a CBCPadEncrypter for the given Block. The length
+// of iv must be the same as the Block's block size.
+func NewCBCPadEncrypter(b cipher.Block, iv []byte) *CBCPadEncrypter {
+	if b.BlockSize() > 255 {
+		panic("rc5: block size too large for padding")
+	}
+	return &CBCPadEncrypter{
+		mode: NewCBCEncrypter(b, iv),
+		buf: make([]byte, b.BlockSize()),
+	}
+}
+
+// Update encrypts src and the input held over from earlier calls, writing
+// every completed block to dst. It returns the number of bytes written, which
+// is at most len(src) + BlockSize() - 1.
+func (e *CBCPadEncrypter) Update(dst, src []byte) int {
+	bs := len(e.buf)
+	written := 0
+
+	if e.n > 0 {
+		c := copy(e.buf[e.n:], src)
+		e.n += c
+		src = src[c:]
+		if e.n < bs {
+			return 0
+		}
+		e.mode.CryptBlocks(dst[:bs], e.buf)
+		dst = dst[bs:]
+		written += bs
+		e.n = 0
+	}
+
+	full := len(src) / bs * bs
+	e.mode.CryptBlocks(dst[:full], src[:full])
+	written += full
+
+	e.n = copy(e.buf, src[full:])
+	return written
+}
+
+// Final pads the held over input and writes the last block, BlockSize()
+// bytes, to dst. The encrypter must be Reset before it is used again.
+func (e *CBCPadEncrypter) Final(dst []byte) int {
+	bs := len(e.buf)
+	pad := byte(bs - e.n)
+	for i := e.n; i < bs; i++ {
+		e.buf[i] = pad
+	}
+	e.mode.CryptBlocks(dst[:bs], e.buf)
+	e.n = 0
+	return bs
+}
+
+// Reset discards any held over input and sets a new IV, as RC5_CBC_SetIV does.
+func (e *CBCPadEncrypter) Reset(iv []byte) {
+	e.mode.(*cbcEncrypter).SetIV(iv)
+	e.n = 0
+}
+
+// CBCPadDecrypter is the RC5-CBC-Pad decryption object of RFC 2040 section 8.
+// The last block is always held back by Update, since it carries the padding
+// that Final checks and removes.
+type CBCPadDecrypter struct {
+	mode 			cipher.BlockMode 	// RC5-CBC
+	buf 			[]byte 				// unprocessed input
+	n 				int 				// number of bytes in buf
+	total 			int 				// number of bytes of input seen
+}
+
+// NewCBCPadDecrypter returns a CBCPadDecrypter for the given Block. The length
+// of iv must be the same as the Block's block size.
+func NewCBCPadDecrypter(b cipher.Block, iv []byte) *CBCPadDecrypter {
+	if b.BlockSize() > 255 {
+		panic("rc5: block size too large for padding")
+	}
+	return &CBCPadDecrypter{
+		mode: NewCBCDecrypter(b, iv),
+		buf: make([]byte, b.BlockSize()),
+	}
+}
+
+// Update decrypts src and the input held over from earlier calls, writing
+// every block except the last to dst. It returns the number of bytes written,
+// which is at most len(src) + BlockSize() - 1.
+func (d *CBCPadDecrypter) Update(dst, src []byte) int {
+	bs := len(d.buf)
+	written := 0
+	d.total += len(src)
+
+	for len(src) > 0 {
+		if d.n == bs {
+			d.mode.CryptBlocks(dst[:bs], d.buf)
+			dst = dst[bs:]
+			written += bs
+			d.n = 0
+		}
+
+		if d.n == 0 && len(src) > bs {
+			full := (len(src) - 1) / bs * bs
+			d.mode.CryptBlocks(dst[:full], src[:full])
+			dst, src = dst[full:], src[full:]
+			written += full
+		}
+
+		c := copy(d.buf[d.n:], src)
+		d.n += c
+		src = src[c:]
+	}
+
+	return written
+}
+
+// Final decrypts the last block, checks and removes its padding and writes
+// what remains, at most BlockSize() - 1 bytes, to dst. It returns an
+// InputSizeError if the input was not a whole number of blocks and a
+// PaddingError if the padding is malformed. The decrypter must be Reset
+// before it is used again.
+func (d *CBCPadDecrypter) Final(dst []byte) (int, error) {
+	bs := len(d.buf)
+	defer func() {
+		d.n, d.total = 0, 0
+	}()
+
+	if d.n != bs || d.total % bs != 0 {
+		return 0, InputSizeError(d.total)
+	}
+
+	d.mode.CryptBlocks(d.buf, d.buf)
+	pad := d.buf[bs - 1]
+
+	// check every pad byte without branching on their values
+	good := subtle.ConstantTimeLessOrEq(1, int(pad)) & subtle.ConstantTimeLessOrEq(int(pad), bs)
+	for i := 0; i < bs; i++ {
+		inPad := subtle.ConstantTimeLessOrEq(bs - int(pad), i)
+		good &= subtle.ConstantTimeByteEq(d.buf[i], pad) | (inPad ^ 1)
+	}
+	if good != 1 {
+		return 0, PaddingError{}
+	}
+
+	return copy(dst, d.buf[:bs - int(pad)]), nil
+}
+
+// Reset discards any held over input and sets a new IV, as RC5_CBC_SetIV does.
+func (d *CBCPadDecrypter) Reset(iv []byte) {
+	d.mode.(*cbcDecrypter).SetIV(iv)
+	d.n, d.total = 0, 0
+}
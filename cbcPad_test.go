@@ -0,0 +1,121 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// cbcPadEncrypt runs src through e in pieces of random length.
+func cbcPadEncrypt(random *rand.Rand, e *CBCPadEncrypter, src []byte) []byte {
+	dst := make([]byte, len(src) + len(e.buf))
+	n := 0
+	for len(src) > 0 {
+		c := random.Intn(len(src) + 1)
+		n += e.Update(dst[n:], src[:c])
+		src = src[c:]
+	}
+	n += e.Final(dst[n:])
+	return dst[:n]
+}
+
+// cbcPadDecrypt runs src through d in pieces of random length.
+func cbcPadDecrypt(random *rand.Rand, d *CBCPadDecrypter, src []byte) ([]byte, error) {
+	dst := make([]byte, len(src) + len(d.buf))
+	n := 0
+	for len(src) > 0 {
+		c := random.Intn(len(src) + 1)
+		n += d.Update(dst[n:], src[:c])
+		src = src[c:]
+	}
+	m, err := d.Final(dst[n:])
+	return dst[:n + m], err
+}
+
+func TestCBCPadVector(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	// RFC 2040 section 10, test vector 25
+	key, _ := hex.DecodeString("0102030405")
+	iv, _ := hex.DecodeString("0000000000000000")
+	plain, _ := hex.DecodeString("ffffffffffffffff")
+	expected, _ := hex.DecodeString("7875dbf6738c64788f34c3c681c99695")
+
+	block, _ := NewCipher32(key, 8)
+
+	encrypted := cbcPadEncrypt(random, NewCBCPadEncrypter(block, iv), plain)
+	if !bytes.Equal(encrypted, expected) {
+		t.Errorf("encrypt == % 02x, want % 02x", encrypted, expected)
+	}
+
+	decrypted, err := cbcPadDecrypt(random, NewCBCPadDecrypter(block, iv), expected)
+	if err != nil || !bytes.Equal(decrypted, plain) {
+		t.Errorf("decrypt == % 02x, %v, want % 02x", decrypted, err, plain)
+	}
+}
+
+func TestCBCPad(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		iv := make([]byte, bs)
+		e := NewCBCPadEncrypter(block, iv)
+		d := NewCBCPadDecrypter(block, iv)
+
+		for size := 0; size < 5 * bs; size++ {
+			random.Read(iv)
+			e.Reset(iv)
+			d.Reset(iv)
+
+			value := make([]byte, size)
+			random.Read(value)
+
+			encrypted := cbcPadEncrypt(random, e, value)
+			if len(encrypted) != (size / bs + 1) * bs {
+				t.Errorf("encrypt(%d, %d bytes) gave %d bytes", wordSize, size, len(encrypted))
+			}
+
+			decrypted, err := cbcPadDecrypt(random, d, encrypted)
+			if err != nil || !bytes.Equal(decrypted, value) {
+				t.Errorf("decrypt(%d, %d bytes) failed: %v", wordSize, size, err)
+			}
+		}
+	}
+}
+
+func TestCBCPadErrors(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	block, _ := NewCipher32(make([]byte, 16), 12)
+	iv := make([]byte, 8)
+	d := NewCBCPadDecrypter(block, iv)
+
+	if _, err := cbcPadDecrypt(random, d, make([]byte, 12)); err != InputSizeError(12) {
+		t.Errorf("decrypt of 12 bytes error == %v, want %v", err, InputSizeError(12))
+	}
+
+	// a final block whose plaintext ends in a zero byte
+	d.Reset(iv)
+	bad := make([]byte, 8)
+	NewCBCEncrypter(block, iv).CryptBlocks(bad, bad)
+	if _, err := cbcPadDecrypt(random, d, bad); err != (PaddingError{}) {
+		t.Errorf("decrypt of bad padding error == %v, want %v", err, PaddingError{})
+	}
+
+	// a final block whose plaintext ends 02 03
+	d.Reset(iv)
+	bad = []byte{0, 0, 0, 0, 0, 0, 2, 3}
+	NewCBCEncrypter(block, iv).CryptBlocks(bad, bad)
+	if _, err := cbcPadDecrypt(random, d, bad); err != (PaddingError{}) {
+		t.Errorf("decrypt of bad padding error == %v, want %v", err, PaddingError{})
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// RC5-CBC test vectors from RFC 2040 section 10, all RC5-32.
+var cbcVectors = []struct {
+	rounds 			uint
+	key 			string
+	iv 				string
+	plain 			string
+	cipher 			string
+}{
+	{0, "00", "0000000000000000", "0000000000000000", "7a7bba4d79111d1e"},
+	{0, "00", "0000000000000000", "ffffffffffffffff", "797bba4d78111d1e"},
+	{0, "00", "0000000000000001", "0000000000000000", "7a7bba4d79111d1f"},
+	{0, "00", "0000000000000000", "0000000000000001", "7a7bba4d79111d1f"},
+	{0, "00", "0102030405060708", "1020304050607080", "8b9ded91ce7794a6"},
+	{1, "11", "0000000000000000", "0000000000000000", "2f759fe7ad86a378"},
+	{2, "00", "0000000000000000", "0000000000000000", "dca2694bf40e0788"},
+	{2, "00000000", "0000000000000000", "0000000000000000", "dca2694bf40e0788"},
+	{8, "00", "0000000000000000", "0000000000000000", "dcfe098577eca5ff"},
+	{8, "00", "0102030405060708", "1020304050607080", "9646fb77638f9ca8"},
+	{12, "00", "0102030405060708", "1020304050607080", "b2b3209db6594da4"},
+	{16, "00", "0102030405060708", "1020304050607080", "545f7f32a5fc3836"},
+	{8, "01020304", "0000000000000000", "ffffffffffffffff", "8285e7c1b5bc7402"},
+	{12, "01020304", "0000000000000000", "ffffffffffffffff", "fc586f92f7080934"},
+	{16, "01020304", "0000000000000000", "ffffffffffffffff", "cf270ef9717ff7c4"},
+	{12, "0102030405060708", "0000000000000000", "ffffffffffffffff", "e493f1c1bb4d6e8c"},
+	{8, "0102030405060708", "0102030405060708", "1020304050607080", "5c4c041e0f217ac3"},
+	{12, "0102030405060708", "0102030405060708", "1020304050607080", "921f12485373b4f7"},
+	{16, "0102030405060708", "0102030405060708", "1020304050607080", "5ba0ca6bbe7f5fad"},
+	{8, "01020304050607081020304050607080", "0102030405060708", "1020304050607080", "c533771cd0110e63"},
+	{12, "01020304050607081020304050607080", "0102030405060708", "1020304050607080", "294ddb46b3278d60"},
+	{16, "01020304050607081020304050607080", "0102030405060708", "1020304050607080", "dad6bda9dfe8f7e8"},
+	{12, "0102030405", "0000000000000000", "ffffffffffffffff", "97e0787837ed317f"},
+	{8, "0102030405", "0000000000000000", "ffffffffffffffff", "7875dbf6738c6478"},
+}
+
+func TestCBCVectors(t *testing.T) {
+	for n, v := range cbcVectors {
+		key, _ := hex.DecodeString(v.key)
+		iv, _ := hex.DecodeString(v.iv)
+		plain, _ := hex.DecodeString(v.plain)
+		expected, _ := hex.DecodeString(v.cipher)
+
+		block, _ := NewCipher32(key, v.rounds)
+
+		encrypted := make([]byte, len(plain))
+		NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("vector %d: encrypt == % 02x, want % 02x", n + 1, encrypted, expected)
+		}
+
+		decrypted := make([]byte, len(expected))
+		NewCBCDecrypter(block, iv).CryptBlocks(decrypted, expected)
+		if !bytes.Equal(decrypted, plain) {
+			t.Errorf("vector %d: decrypt == % 02x, want % 02x", n + 1, decrypted, plain)
+		}
+	}
+}
+
+func TestCBCEncrypter(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		iv := make([]byte, bs)
+		random.Read(iv)
+
+		value := make([]byte, 9 * bs)
+		random.Read(value)
+		expected := make([]byte, len(value))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(expected, value)
+
+		encrypter := NewCBCEncrypter(block, iv)
+		encrypted := make([]byte, len(value))
+		copy(encrypted, value)
+		encrypter.CryptBlocks(encrypted[:4 * bs], encrypted[:4 * bs])
+		encrypter.CryptBlocks(encrypted[4 * bs:], encrypted[4 * bs:])
+
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("CBC encrypt(%d) failed: % 02x != % 02x", wordSize, encrypted, expected)
+		}
+	}
+}
+
+func TestCBCIVLength(t *testing.T) {
+	block, _ := NewCipher64(make([]byte, 16), 12)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewCBCEncrypter accepted a 64-bit IV for a 128-bit block")
+		}
+	}()
+	NewCBCEncrypter(block, make([]byte, 8))
+}
+
+func TestCBCDecrypter(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		iv := make([]byte, bs)
+		random.Read(iv)
+
+		for _, size := range []int{1, 3, 4, 9, cbcParallelThreshold / bs + 5} {
+			value := make([]byte, size * bs)
+			random.Read(value)
+			encrypted := make([]byte, len(value))
+			cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, value)
+
+			for _, workers := range []int{1, 3, 8} {
+				decrypter := NewCBCDecrypter(block, iv).(*cbcDecrypter)
+				decrypter.workers = workers
+
+				decrypted := make([]byte, len(encrypted))
+				half := size / 2 * bs
+				decrypter.CryptBlocks(decrypted[:half], encrypted[:half])
+				decrypter.CryptBlocks(decrypted[half:], encrypted[half:])
+
+				if !bytes.Equal(decrypted, value) {
+					t.Errorf("CBC decrypt(%d, %d blocks, %d workers) failed", wordSize, size, workers)
+				}
+
+				inPlace := make([]byte, len(encrypted))
+				copy(inPlace, encrypted)
+				decrypter.SetIV(iv)
+				decrypter.CryptBlocks(inPlace, inPlace)
+
+				if !bytes.Equal(inPlace, value) {
+					t.Errorf("in-place CBC decrypt(%d, %d blocks, %d workers) failed", wordSize, size, workers)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkCBCDecrypter(b *testing.B) {
+	key := make([]byte, 16)
+	block, _ := NewCipher32(key, 12)
+	iv := make([]byte, block.BlockSize())
+	buf := make([]byte, 1 << 20)
+	decrypter := NewCBCDecrypter(block, iv)
+
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		decrypter.CryptBlocks(buf, buf)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// CFB-n, cipher feedback with n-bit segments for n a multiple of 8 up to the
+// block size, as in NIST SP 800-38A. Each segment of ciphertext is shifted
+// into the input block, so CFB-8 costs one block encryption per byte.
+type cfb struct {
+	b 				cipher.Block 	// block cipher
+	reg 			[]byte 			// input block, the shift register
+	out 			[]byte 			// output block for the current segment
+	seg 			[]byte 			// ciphertext of the current segment
+	pos 			int 			// bytes of the current segment done
+	decrypt 		bool 			// whether ciphertext is the input
+}
+
+func newCFB(b cipher.Block, iv []byte, segmentSize int, decrypt bool) cipher.Stream {
+	bs := b.BlockSize()
+	if len(iv) != bs {
+		panic("rc5.NewCFB: IV length must equal block size")
+	}
+	if segmentSize < 1 || segmentSize > bs {
+		panic("rc5.NewCFB: segment size must be between 1 byte and the block size")
+	}
+
+	x := cfb{
+		b: b,
+		reg: make([]byte, bs),
+		out: make([]byte, bs),
+		seg: make([]byte, segmentSize),
+		decrypt: decrypt,
+	}
+	copy(x.reg, iv)
+	return &x
+}
+
+// NewCFBEncrypter returns a Stream which encrypts with cipher feedback mode
+// in segments of segmentSize bytes, using the given Block. The length of iv
+// must be the same as the Block's block size.
+func NewCFBEncrypter(b cipher.Block, iv []byte, segmentSize int) cipher.Stream {
+	return newCFB(b, iv, segmentSize, false)
+}
+
+// NewCFBDecrypter returns a Stream which decrypts with cipher feedback mode
+// in segments of segmentSize bytes, using the given Block. The length of iv
+// must be the same as the Block's block size.
+func NewCFBDecrypter(b cipher.Block, iv []byte, segmentSize int) cipher.Stream {
+	return newCFB(b, iv, segmentSize, true)
+}
+
+func (x *cfb) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	for i := 0; i < len(src); i++ {
+		if x.pos == 0 {
+			x.b.Encrypt(x.out, x.reg)
+		}
+
+		in := src[i]
+		dst[i] = in ^ x.out[x.pos]
+		if x.decrypt {
+			x.seg[x.pos] = in
+		} else {
+			x.seg[x.pos] = dst[i]
+		}
+
+		x.pos++
+		if x.pos == len(x.seg) {
+			shiftIn(x.reg, x.seg)
+			x.pos = 0
+		}
+	}
+}
+
+// shiftIn shifts reg left by len(seg) bytes and fills the end with seg.
+func shiftIn(reg, seg []byte) {
+	n := copy(reg, reg[len(seg):])
+	copy(reg[n:], seg)
+}
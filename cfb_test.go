@@ -0,0 +1,74 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func cfbStream(f func(cipher.Block, []byte, int) cipher.Stream, segmentSize int) func(cipher.Block, []byte) func(dst, src []byte) {
+	return func(b cipher.Block, iv []byte) func(dst, src []byte) {
+		return f(b, iv, segmentSize).XORKeyStream
+	}
+}
+
+func TestCFB8Vector(t *testing.T) {
+	// CFB8-AES128 from NIST SP 800-38A F.3.7, checking the mode itself
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	plain, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d")
+	expected, _ := hex.DecodeString("3b79424c9c0dd436bace9e0ed4586a4f32b9")
+	block, _ := aes.NewCipher(key)
+
+	encrypted := make([]byte, len(plain))
+	NewCFBEncrypter(block, seqBytes(16), 1).XORKeyStream(encrypted, plain)
+	if !bytes.Equal(encrypted, expected) {
+		t.Errorf("CFB8 == % 02x, want % 02x", encrypted, expected)
+	}
+}
+
+// cfb8Reference xors each byte with the first byte of the encrypted shift
+// register, then shifts the ciphertext byte in.
+func cfb8Reference(b cipher.Block, iv, src []byte) []byte {
+	dst := make([]byte, len(src))
+	register := append([]byte(nil), iv...)
+	out := make([]byte, len(iv))
+	for i := range src {
+		b.Encrypt(out, register)
+		dst[i] = src[i] ^ out[0]
+		register = append(register[1:], dst[i])
+	}
+	return dst
+}
+
+func TestCFB8(t *testing.T) {
+	testLegacyMode(t, "CFB8", 1, cfb8Reference, cfbStream(NewCFBEncrypter, 1), cfbStream(NewCFBDecrypter, 1), false)
+}
+
+func TestCFBFullBlock(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		block, _ := NewCipher(seqBytes(16), 12, wordSize)
+		bs := block.BlockSize()
+		iv := seqBytes(bs)
+		value := make([]byte, 5 * bs + 3)
+		random.Read(value)
+
+		// with whole block segments CFB-n is ordinary CFB
+		expected := make([]byte, len(value))
+		cipher.NewCFBEncrypter(block, iv).XORKeyStream(expected, value)
+		encrypted := make([]byte, len(value))
+		NewCFBEncrypter(block, iv, bs).XORKeyStream(encrypted, value)
+
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("CFB(%d) with whole block segments differs from cipher.NewCFBEncrypter", wordSize)
+		}
+	}
+}
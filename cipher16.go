@@ -78,10 +78,22 @@ func newCipher16(key []byte, rounds uint) (*cipher16, error) {
     return &c, nil
 }
 
-func (c *cipher16) BlockSize() int { return B16 }
+func (c *cipher16) BlockSize() int { return BB16 }
 
 func (c *cipher16) Encrypt(dst, src []byte) {
 	A, B := get16(src)
+	A, B = c.encryptWords(A, B)
+	put16(dst, A, B)
+}
+
+func (c *cipher16) Decrypt(dst, src []byte) {
+	A, B := get16(src)
+	A, B = c.decryptWords(A, B)
+	put16(dst, A, B)
+}
+
+// encryptWords encrypts the block whose low and high words are A and B.
+func (c *cipher16) encryptWords(A, B uint16) (uint16, uint16) {
 	A, B = A + c.S[0], B + c.S[1]
 
 	for i := uint(1); i <= c.R; i++ {
@@ -89,21 +101,51 @@ func (c *cipher16) Encrypt(dst, src []byte) {
 		B = rotl16(B^A, A&15) + c.S[2 * i + 1]
 	}
 
-	put16(dst, A, B)
+	return A, B
 }
 
-func (c *cipher16) Decrypt(dst, src []byte) {
-	A, B := get16(src)
-
+// decryptWords decrypts the block whose low and high words are A and B.
+func (c *cipher16) decryptWords(A, B uint16) (uint16, uint16) {
 	for i := int(c.R); i >= 1; i-- {
 		B = rotr16(B - c.S[2 *i + 1], A&15) ^ A
 		A = rotr16(A - c.S[2 * i], B&15) ^ B
 	}
 
-	B = B - c.S[1]
-	A = A - c.S[0]
+	return A - c.S[0], B - c.S[1]
+}
 
-	put16(dst, A, B)
+// decryptBlocks decrypts a whole number of blocks from src into dst,
+// interleaving four blocks at a time. dst and src may overlap entirely.
+func (c *cipher16) decryptBlocks(dst, src []byte) {
+	for len(src) >= 4 * BB16 {
+		A0, B0 := get16(src[0:])
+		A1, B1 := get16(src[BB16:])
+		A2, B2 := get16(src[2 * BB16:])
+		A3, B3 := get16(src[3 * BB16:])
+
+		for i := c.R; i >= 1; i-- {
+			B0 = rotr16(B0 - c.S[2 * i + 1], A0&15) ^ A0
+			B1 = rotr16(B1 - c.S[2 * i + 1], A1&15) ^ A1
+			B2 = rotr16(B2 - c.S[2 * i + 1], A2&15) ^ A2
+			B3 = rotr16(B3 - c.S[2 * i + 1], A3&15) ^ A3
+			A0 = rotr16(A0 - c.S[2 * i], B0&15) ^ B0
+			A1 = rotr16(A1 - c.S[2 * i], B1&15) ^ B1
+			A2 = rotr16(A2 - c.S[2 * i], B2&15) ^ B2
+			A3 = rotr16(A3 - c.S[2 * i], B3&15) ^ B3
+		}
+
+		put16(dst[0:], A0 - c.S[0], B0 - c.S[1])
+		put16(dst[BB16:], A1 - c.S[0], B1 - c.S[1])
+		put16(dst[2 * BB16:], A2 - c.S[0], B2 - c.S[1])
+		put16(dst[3 * BB16:], A3 - c.S[0], B3 - c.S[1])
+
+		dst, src = dst[4 * BB16:], src[4 * BB16:]
+	}
+
+	for len(src) > 0 {
+		c.Decrypt(dst, src)
+		dst, src = dst[BB16:], src[BB16:]
+	}
 }
 
 func newKeyTable16(R uint) ([]uint16, uint) {
@@ -119,12 +161,15 @@ func newKeyTable16(R uint) ([]uint16, uint) {
 }
 
 func bytesToWords16(key []byte) ([]uint16, uint) {
-	LL := uint(len(key) / WW16)
+	// pad the key with zero bytes to a whole number of words, at least one
+	LL := uint((len(key) + WW16 - 1) / WW16)
+	if LL == 0 {
+		LL = 1
+	}
 	L := make([]uint16, LL)
 
-	for i := uint(0); i < LL; i++ {
-		L[i] = getUint16(key[:WW16])
-		key = key[WW16:]
+	for i := 0; i < len(key); i++ {
+		L[i / WW16] |= uint16(key[i]) << (8 * uint(i % WW16))
 	}
 
 	return L, LL
@@ -69,7 +69,7 @@ func NewCipher32(key []byte, rounds uint) (cipher.Block, error) {
 	if n := len(key); n > 255 {
 		return nil, KeySizeError(n)
 	}
-	return newCipher32(key, 12)
+	return newCipher32(key, rounds)
 }
 
 func newCipher32(key []byte, rounds uint) (*cipher32, error) {
@@ -87,10 +87,22 @@ func newCipher32(key []byte, rounds uint) (*cipher32, error) {
     return &c, nil
 }
 
-func (c *cipher32) BlockSize() int { return B32 }
+func (c *cipher32) BlockSize() int { return BB32 }
 
 func (c *cipher32) Encrypt(dst, src []byte) {
 	A, B := get32(src)
+	A, B = c.encryptWords(A, B)
+	put32(dst, A, B)
+}
+
+func (c *cipher32) Decrypt(dst, src []byte) {
+	A, B := get32(src)
+	A, B = c.decryptWords(A, B)
+	put32(dst, A, B)
+}
+
+// encryptWords encrypts the block whose low and high words are A and B.
+func (c *cipher32) encryptWords(A, B uint32) (uint32, uint32) {
 	A, B = A + c.S[0], B + c.S[1]
 
 	for i := uint(1); i <= c.R; i++ {
@@ -98,21 +110,51 @@ func (c *cipher32) Encrypt(dst, src []byte) {
 		B = rotl32(B^A, A&31) + c.S[2 * i + 1]
 	}
 
-	put32(dst, A, B)
+	return A, B
 }
 
-func (c *cipher32) Decrypt(dst, src []byte) {
-	A, B := get32(src)
-
+// decryptWords decrypts the block whose low and high words are A and B.
+func (c *cipher32) decryptWords(A, B uint32) (uint32, uint32) {
 	for i := int(c.R); i >= 1; i-- {
 		B = rotr32(B - c.S[2 *i + 1], A&31) ^ A
 		A = rotr32(A - c.S[2 * i], B&31) ^ B
 	}
 
-	B = B - c.S[1]
-	A = A - c.S[0]
+	return A - c.S[0], B - c.S[1]
+}
 
-	put32(dst, A, B)
+// decryptBlocks decrypts a whole number of blocks from src into dst,
+// interleaving four blocks at a time. dst and src may overlap entirely.
+func (c *cipher32) decryptBlocks(dst, src []byte) {
+	for len(src) >= 4 * BB32 {
+		A0, B0 := get32(src[0:])
+		A1, B1 := get32(src[BB32:])
+		A2, B2 := get32(src[2 * BB32:])
+		A3, B3 := get32(src[3 * BB32:])
+
+		for i := c.R; i >= 1; i-- {
+			B0 = rotr32(B0 - c.S[2 * i + 1], A0&31) ^ A0
+			B1 = rotr32(B1 - c.S[2 * i + 1], A1&31) ^ A1
+			B2 = rotr32(B2 - c.S[2 * i + 1], A2&31) ^ A2
+			B3 = rotr32(B3 - c.S[2 * i + 1], A3&31) ^ A3
+			A0 = rotr32(A0 - c.S[2 * i], B0&31) ^ B0
+			A1 = rotr32(A1 - c.S[2 * i], B1&31) ^ B1
+			A2 = rotr32(A2 - c.S[2 * i], B2&31) ^ B2
+			A3 = rotr32(A3 - c.S[2 * i], B3&31) ^ B3
+		}
+
+		put32(dst[0:], A0 - c.S[0], B0 - c.S[1])
+		put32(dst[BB32:], A1 - c.S[0], B1 - c.S[1])
+		put32(dst[2 * BB32:], A2 - c.S[0], B2 - c.S[1])
+		put32(dst[3 * BB32:], A3 - c.S[0], B3 - c.S[1])
+
+		dst, src = dst[4 * BB32:], src[4 * BB32:]
+	}
+
+	for len(src) > 0 {
+		c.Decrypt(dst, src)
+		dst, src = dst[BB32:], src[BB32:]
+	}
 }
 
 func newKeyTable32(R uint) ([]uint32, uint) {
@@ -128,12 +170,15 @@ func newKeyTable32(R uint) ([]uint32, uint) {
 }
 
 func bytesToWords32(key []byte, blockSize uint) ([]uint32, uint) {
-	LL := uint(len(key) / WW32)
+	// pad the key with zero bytes to a whole number of words, at least one
+	LL := uint((len(key) + WW32 - 1) / WW32)
+	if LL == 0 {
+		LL = 1
+	}
 	L := make([]uint32, LL)
 
-	for i := uint(0); i < LL; i++ {
-		L[i] = getUint32(key[:WW32])
-		key = key[WW32:]
+	for i := 0; i < len(key); i++ {
+		L[i / WW32] |= uint32(key[i]) << (8 * uint(i % WW32))
 	}
 
 	return L, LL
@@ -103,7 +103,7 @@ func newCipher64(key []byte, rounds uint) (*cipher64, error) {
     return &c, nil
 }
 
-func (c *cipher64) BlockSize() int { return B64 }
+func (c *cipher64) BlockSize() int { return BB64 }
 
 func (c *cipher64) Encrypt(dst, src []byte) {
 	A, B := get64(src)
@@ -131,6 +131,40 @@ func (c *cipher64) Decrypt(dst, src []byte) {
 	put64(dst, A, B)
 }
 
+// decryptBlocks decrypts a whole number of blocks from src into dst,
+// interleaving four blocks at a time. dst and src may overlap entirely.
+func (c *cipher64) decryptBlocks(dst, src []byte) {
+	for len(src) >= 4 * BB64 {
+		A0, B0 := get64(src[0:])
+		A1, B1 := get64(src[BB64:])
+		A2, B2 := get64(src[2 * BB64:])
+		A3, B3 := get64(src[3 * BB64:])
+
+		for i := c.R; i >= 1; i-- {
+			B0 = rotr64(B0 - c.S[2 * i + 1], A0&63) ^ A0
+			B1 = rotr64(B1 - c.S[2 * i + 1], A1&63) ^ A1
+			B2 = rotr64(B2 - c.S[2 * i + 1], A2&63) ^ A2
+			B3 = rotr64(B3 - c.S[2 * i + 1], A3&63) ^ A3
+			A0 = rotr64(A0 - c.S[2 * i], B0&63) ^ B0
+			A1 = rotr64(A1 - c.S[2 * i], B1&63) ^ B1
+			A2 = rotr64(A2 - c.S[2 * i], B2&63) ^ B2
+			A3 = rotr64(A3 - c.S[2 * i], B3&63) ^ B3
+		}
+
+		put64(dst[0:], A0 - c.S[0], B0 - c.S[1])
+		put64(dst[BB64:], A1 - c.S[0], B1 - c.S[1])
+		put64(dst[2 * BB64:], A2 - c.S[0], B2 - c.S[1])
+		put64(dst[3 * BB64:], A3 - c.S[0], B3 - c.S[1])
+
+		dst, src = dst[4 * BB64:], src[4 * BB64:]
+	}
+
+	for len(src) > 0 {
+		c.Decrypt(dst, src)
+		dst, src = dst[BB64:], src[BB64:]
+	}
+}
+
 func newKeyTable64(R uint) ([]uint64, uint) {
 	T := 2 * (R + 1)
 	S := make([]uint64, T)
@@ -144,12 +178,15 @@ func newKeyTable64(R uint) ([]uint64, uint) {
 }
 
 func bytesToWords64(key []byte, blockSize uint) ([]uint64, uint) {
-	LL := uint(len(key) / WW64)
+	// pad the key with zero bytes to a whole number of words, at least one
+	LL := uint((len(key) + WW64 - 1) / WW64)
+	if LL == 0 {
+		LL = 1
+	}
 	L := make([]uint64, LL)
 
-	for i := uint(0); i < LL; i++ {
-		L[i] = getUint64(key[:WW64])
-		key = key[WW64:]
+	for i := 0; i < len(key); i++ {
+		L[i / WW64] |= uint64(key[i]) << (8 * uint(i % WW64))
 	}
 
 	return L, LL
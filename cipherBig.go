@@ -7,6 +7,7 @@ package rc5
 import (
 	"crypto/cipher"
 	"math/big"
+	"math/bits"
 	"scorpioncompute.com/bigmath"
 )
 
@@ -84,7 +85,7 @@ func newCipherBig(key []byte, rounds uint, wordSize uint) (*cipherBig, error) {
     return &cipher, nil
 }
 
-func (c *cipherBig) BlockSize() int { return int(c.B) }
+func (c *cipherBig) BlockSize() int { return int(c.BB) }
 
 func (c *cipherBig) Encrypt(dst, src []byte) {
 	SRC := make([]byte, len(src))
@@ -97,10 +98,10 @@ func (c *cipherBig) Encrypt(dst, src []byte) {
 
 	for i := 1; i <= int(c.R); i++ {
 		A = A.Xor(A, B)
-		A = c.ROTL(A, uint(B.Uint64())&(c.W - 1))
+		A = c.ROTL(A, rotation(B, c.W))
 		A.Add(A, c.S[2 * i])
 		B = B.Xor(B, A)
-		B = c.ROTL(B, uint(A.Uint64())&(c.W - 1))
+		B = c.ROTL(B, rotation(A, c.W))
 		B.Add(B, c.S[2 * i + 1])
 	}
 	
@@ -119,10 +120,10 @@ func (c *cipherBig) Decrypt(dst, src []byte) {
 
 	for i := int(c.R); i >= 1; i-- {
 		B.Sub(B, c.S[2 * i + 1])
-		B = c.ROTR(B, uint(A.Uint64())&(c.W - 1))
+		B = c.ROTR(B, rotation(A, c.W))
 		B = B.Xor(B, A)
 		A.Sub(A, c.S[2 * i])
-		A = c.ROTR(A, uint(B.Uint64())&(c.W - 1))
+		A = c.ROTR(A, rotation(B, c.W))
 		A = A.Xor(A, B)
 	}
 
@@ -151,6 +152,13 @@ func newKeyTable(R uint, W uint) ([]*big.Int, uint) {
     return S, T
 }
 
+// rotation is the rotation amount for x, its low floor(lg W) bits. When W
+// is a power of two that is x mod W; otherwise it stays below W, as the
+// test vectors for RC5-24 and RC5-80 take it.
+func rotation(x *big.Int, W uint) uint {
+	return uint(x.Uint64()) & (1 << uint(bits.Len(W) - 1) - 1)
+}
+
 func newRotate(s uint) (rot, rot) {
 	mask := bigmath.Mask(s)
 
@@ -183,8 +191,12 @@ func reverse(bytes []byte) []byte {
 }
 
 func bytesToWords(key []byte, WW uint) ([]*big.Int, uint) {
-	LL := uint(len(key)) / WW
-	K := make([]byte, len(key))
+	// pad the key with zero bytes to a whole number of words, at least one
+	LL := (uint(len(key)) + WW - 1) / WW
+	if LL == 0 {
+		LL = 1
+	}
+	K := make([]byte, LL * WW)
 	copy(K, key)
 	L := make([]*big.Int, LL)
 	for i := uint(0); i < LL; i++ {
@@ -208,7 +220,8 @@ func expandKeyTable(S []*big.Int, T uint, L []*big.Int, LL uint, ROTL rot, W uin
 	for ; k > 0; k-- {
 		S[i] = ROTL(S[i].Add(S[i], A).Add(S[i], B), 3)
 		A = new(big.Int).Set(S[i])
-        L[j] = ROTL(L[j].Add(L[j], A).Add(L[j], B), uint(A.Uint64() + B.Uint64())&(W - 1))
+        AB := new(big.Int).Add(A, B)
+        L[j] = ROTL(L[j].Add(L[j], AB), rotation(AB, W))
         B = new(big.Int).Set(L[j])
         i = (i + 1) % T;
         j = (j + 1) % LL;
@@ -6,6 +6,7 @@ package rc5
 
 import (
 	"bytes"
+	"encoding/hex"
 	"math/big"
 	"math/rand"
 	"testing"
@@ -93,6 +94,39 @@ func TestCipherBig64(t *testing.T) {
 	}
 }
 
+func TestCipherBigOddWordSize(t *testing.T) {
+	// RC5-24/4/0 and RC5-80/4/12 from the RC5 test vectors of Kelsey,
+	// Schneier and Krovetz, whose word sizes are not powers of two
+	for _, v := range []struct {
+		W 				uint
+		R 				uint
+		key 			string
+		plain 			string
+		cipher 			string
+	}{
+		{24, 4, "", "000102030405", "89cbdcc9525a"},
+		{80, 4, "000102030405060708090a0b",
+			"000102030405060708090a0b0c0d0e0f10111213",
+			"9cb59ecba4ea84568a4278b0e132d5fc9d5819d6"},
+	} {
+		key, _ := hex.DecodeString(v.key)
+		plain, _ := hex.DecodeString(v.plain)
+		expected, _ := hex.DecodeString(v.cipher)
+		c, _ := NewCipherBig(key, v.R, v.W)
+
+		encrypted := make([]byte, len(plain))
+		c.Encrypt(encrypted, plain)
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("RC5-%d/%d encrypt == % 02x, want % 02x", v.W, v.R, encrypted, expected)
+		}
+		decrypted := make([]byte, len(plain))
+		c.Decrypt(decrypted, expected)
+		if !bytes.Equal(decrypted, plain) {
+			t.Errorf("RC5-%d/%d decrypt == % 02x, want % 02x", v.W, v.R, decrypted, plain)
+		}
+	}
+}
+
 func TestP(t *testing.T) {
 	var p_values = []struct {
 		w uint
@@ -0,0 +1,22 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"testing"
+)
+
+func TestBlockSize(t *testing.T) {
+	// the block is two words, and cipher.Block counts it in bytes
+	for _, wordSize := range []uint{8, 16, 24, 32, 64, 128, 256} {
+		block, err := NewCipher(make([]byte, 16), 12, wordSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bs := block.BlockSize(); bs != int(2 * wordSize / 8) {
+			t.Errorf("RC5-%d BlockSize() == %d, want %d", wordSize, bs, 2 * wordSize / 8)
+		}
+	}
+}
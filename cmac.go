@@ -0,0 +1,147 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// CMAC is the message authentication code of NIST SP 800-38B, also known as
+// OMAC1, over any block size with a doubling polynomial. It implements
+// hash.Hash; Sum appends a tag of Size bytes.
+type CMAC struct {
+	b 				cipher.Block 	// block cipher
+	tagSize 		int 			// tag size in bytes
+	k1 				[]byte 			// subkey for whole final blocks
+	k2 				[]byte 			// subkey for padded final blocks
+	mac 			[]byte 			// CBC-MAC of the blocks before buf
+	buf 			[]byte 			// last, possibly partial, block
+	n 				int 			// bytes in buf
+}
+
+// NewCMAC returns a CMAC keyed by the given Block, which may have any block
+// size from 16 to 1024 bits, so every RC5 word size from 8 to 512. Tags are
+// truncated to tagSize bytes, at most one block; SP 800-38B advises at least
+// 64 bits.
+func NewCMAC(b cipher.Block, tagSize int) (*CMAC, error) {
+	bs := b.BlockSize()
+	if !hasReduction(bs) {
+		return nil, BlockSizeError(bs)
+	}
+	if tagSize < 1 || tagSize > bs {
+		return nil, TagSizeError(tagSize)
+	}
+
+	k1, k2 := cmacSubkeys(b)
+	return &CMAC{
+		b: b,
+		tagSize: tagSize,
+		k1: k1,
+		k2: k2,
+		mac: make([]byte, bs),
+		buf: make([]byte, bs),
+	}, nil
+}
+
+func (c *CMAC) Size() int { return c.tagSize }
+
+func (c *CMAC) BlockSize() int { return len(c.buf) }
+
+func (c *CMAC) Reset() {
+	for i := range c.mac {
+		c.mac[i] = 0
+	}
+	c.n = 0
+}
+
+func (c *CMAC) Write(p []byte) (int, error) {
+	bs := len(c.buf)
+	written := len(p)
+
+	for len(p) > 0 {
+		// a full buffer may be the final block, so it is only chained in
+		// once more data arrives
+		if c.n == bs {
+			xorBytes(c.mac, c.mac, c.buf)
+			c.b.Encrypt(c.mac, c.mac)
+			c.n = 0
+		}
+		n := copy(c.buf[c.n:], p)
+		c.n += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Sum appends the tag of the data written so far to in. It does not change
+// the underlying state.
+func (c *CMAC) Sum(in []byte) []byte {
+	bs := len(c.buf)
+	mac := make([]byte, bs)
+	copy(mac, c.mac)
+
+	xorBytes(mac, mac, c.buf[:c.n])
+	if c.n == bs {
+		xorBytes(mac, mac, c.k1)
+	} else {
+		mac[c.n] ^= 0x80
+		xorBytes(mac, mac, c.k2)
+	}
+	c.b.Encrypt(mac, mac)
+
+	return append(in, mac[:c.tagSize]...)
+}
+
+// Verify reports, in constant time, whether tag is the tag of the data
+// written so far.
+func (c *CMAC) Verify(tag []byte) bool {
+	return subtle.ConstantTimeCompare(c.Sum(nil), tag) == 1
+}
+
+// cmacSubkeys derives the CMAC (OMAC1) subkeys K1 = 2L and K2 = 4L,
+// where L is the encryption of the zero block.
+func cmacSubkeys(b cipher.Block) ([]byte, []byte) {
+	bs := b.BlockSize()
+	k1 := make([]byte, bs)
+	k2 := make([]byte, bs)
+	b.Encrypt(k1, k1)
+	double(k1, k1)
+	double(k2, k1)
+	return k1, k2
+}
+
+// cmacUpdate chains the final, non-empty message data into the CBC-MAC state
+// mac, whitening the last block with k1 or, after 10* padding, with k2.
+func cmacUpdate(b cipher.Block, k1, k2 []byte, mac, data []byte) {
+	bs := len(mac)
+	for len(data) > bs {
+		xorBytes(mac, mac, data)
+		b.Encrypt(mac, mac)
+		data = data[bs:]
+	}
+
+	xorBytes(mac, mac, data)
+	if len(data) == bs {
+		xorBytes(mac, mac, k1)
+	} else {
+		mac[len(data)] ^= 0x80
+		xorBytes(mac, mac, k2)
+	}
+	b.Encrypt(mac, mac)
+}
+
+// cmacSum is the CMAC of msg, which may be empty.
+func cmacSum(b cipher.Block, k1, k2 []byte, msg []byte) []byte {
+	mac := make([]byte, b.BlockSize())
+	if len(msg) == 0 {
+		mac[0] = 0x80
+		xorBytes(mac, mac, k2)
+		b.Encrypt(mac, mac)
+		return mac
+	}
+	cmacUpdate(b, k1, k2, mac, msg)
+	return mac
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"hash"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+var _ hash.Hash = (*CMAC)(nil)
+
+func TestCMACVectors(t *testing.T) {
+	// AES-128 CMAC from RFC 4493 section 4, checking the construction
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51" +
+		"30c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	block, _ := aes.NewCipher(key)
+
+	for _, v := range []struct {
+		length 			int
+		tag 			string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	} {
+		mac, _ := NewCMAC(block, 16)
+		mac.Write(msg[:v.length])
+		expected, _ := hex.DecodeString(v.tag)
+		if tag := mac.Sum(nil); !bytes.Equal(tag, expected) {
+			t.Errorf("CMAC(%d bytes) == % 02x, want % 02x", v.length, tag, expected)
+		}
+	}
+}
+
+// cmacReference is CMAC as NIST SP 800-38B writes it: the subkeys doubled
+// as polynomials with math/big, and the whitened, padded message run through
+// the standard library's CBC encrypter from a zero IV.
+func cmacReference(b cipher.Block, msg []byte) []byte {
+	bs := b.BlockSize()
+	L := make([]byte, bs)
+	b.Encrypt(L, L)
+
+	bits := uint(8 * bs)
+	poly := new(big.Int).Lsh(big.NewInt(1), bits)
+	poly.Or(poly, big.NewInt(int64(reductions[bs])))
+	dbl := func(x *big.Int) *big.Int {
+		x = new(big.Int).Lsh(x, 1)
+		if x.Bit(int(bits)) == 1 {
+			x.Xor(x, poly)
+		}
+		return x
+	}
+	k1 := dbl(new(big.Int).SetBytes(L))
+	k := k1
+
+	padded := append([]byte(nil), msg...)
+	if len(msg) == 0 || len(msg) % bs != 0 {
+		padded = append(padded, 0x80)
+		for len(padded) % bs != 0 {
+			padded = append(padded, 0)
+		}
+		k = dbl(k1)
+	}
+	whitening := make([]byte, bs)
+	kb := k.Bytes()
+	copy(whitening[bs - len(kb):], kb)
+	last := padded[len(padded) - bs:]
+	xorBytes(last, last, whitening)
+
+	cipher.NewCBCEncrypter(b, make([]byte, bs)).CryptBlocks(padded, padded)
+	return padded[len(padded) - bs:]
+}
+
+func TestCMACRC5(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		for _, size := range []int{0, 1, bs, 3 * bs - 1, 3 * bs} {
+			msg := make([]byte, size)
+			random.Read(msg)
+			expected := cmacReference(block, msg)
+
+			mac, _ := NewCMAC(block, bs)
+			mac.Write(msg)
+			if tag := mac.Sum(nil); !bytes.Equal(tag, expected) {
+				t.Errorf("CMAC(%d, %d bytes) == % 02x, want % 02x", wordSize, size, tag, expected)
+			}
+
+			// truncated tags are prefixes, and verify in full or not at all
+			short, _ := NewCMAC(block, bs / 2)
+			short.Write(msg)
+			if tag := short.Sum(nil); !bytes.Equal(tag, expected[:bs / 2]) {
+				t.Errorf("truncated CMAC(%d, %d bytes) == % 02x, want % 02x", wordSize, size, tag, expected[:bs / 2])
+			}
+			if !short.Verify(expected[:bs / 2]) {
+				t.Errorf("CMAC(%d, %d bytes) rejected its own tag", wordSize, size)
+			}
+			if short.Verify(expected) || short.Verify(expected[:bs / 2 - 1]) {
+				t.Errorf("CMAC(%d, %d bytes) accepted a tag of the wrong length", wordSize, size)
+			}
+		}
+	}
+}
+
+func TestCMACWrite(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+		k1, k2 := cmacSubkeys(block)
+		mac, _ := NewCMAC(block, bs)
+
+		for _, size := range []int{0, 1, bs - 1, bs, bs + 1, 5 * bs} {
+			msg := make([]byte, size)
+			random.Read(msg)
+			expected := cmacSum(block, k1, k2, msg)
+
+			// write in random pieces, summing part way through
+			mac.Reset()
+			for n := 0; n < size; {
+				m := n + random.Intn(bs + 2)
+				if m > size {
+					m = size
+				}
+				mac.Write(msg[n:m])
+				mac.Sum(nil)
+				n = m
+			}
+
+			if tag := mac.Sum(nil); !bytes.Equal(tag, expected) {
+				t.Errorf("CMAC(%d, %d bytes) written in pieces == % 02x, want % 02x", wordSize, size, tag, expected)
+			}
+		}
+	}
+}
+
+func TestCMACSizes(t *testing.T) {
+	block, _ := NewCipher32(make([]byte, 16), 12)
+	for _, tagSize := range []int{0, 9} {
+		if _, err := NewCMAC(block, tagSize); err != TagSizeError(tagSize) {
+			t.Errorf("NewCMAC(tagSize %d) error == %v, want TagSizeError", tagSize, err)
+		}
+	}
+
+	block, _ = NewCipher(make([]byte, 16), 12, 24)
+	if _, err := NewCMAC(block, 4); err != BlockSizeError(6) {
+		t.Errorf("NewCMAC(48-bit block) error == %v, want BlockSizeError", err)
+	}
+}
@@ -13,3 +13,102 @@ type KeySizeError int
 func (k KeySizeError) Error() string {
 	return "scorpioncompute.com/rc5: invalid key size " + strconv.Itoa(int(k))
 }
+
+type SelfTestError struct {
+	Implementation 	string 			// cipher that failed
+	Stage 			string 			// "key setup", "encrypt" or "decrypt"
+}
+
+func (e *SelfTestError) Error() string {
+	return "scorpioncompute.com/rc5: self-test failed: " + e.Implementation + " " + e.Stage
+}
+
+type InputSizeError int
+
+func (i InputSizeError) Error() string {
+	return "scorpioncompute.com/rc5: invalid input size " + strconv.Itoa(int(i))
+}
+
+type PaddingError struct{}
+
+func (p PaddingError) Error() string {
+	return "scorpioncompute.com/rc5: invalid padding"
+}
+
+type NonceSizeError int
+
+func (n NonceSizeError) Error() string {
+	return "scorpioncompute.com/rc5: invalid nonce size " + strconv.Itoa(int(n))
+}
+
+type BlockSizeError int
+
+func (b BlockSizeError) Error() string {
+	return "scorpioncompute.com/rc5: unsupported block size " + strconv.Itoa(int(b))
+}
+
+type TagSizeError int
+
+func (t TagSizeError) Error() string {
+	return "scorpioncompute.com/rc5: invalid tag size " + strconv.Itoa(int(t))
+}
+
+type AuthenticationError struct{}
+
+func (a AuthenticationError) Error() string {
+	return "scorpioncompute.com/rc5: message authentication failed"
+}
+
+type ComponentCountError int
+
+func (c ComponentCountError) Error() string {
+	return "scorpioncompute.com/rc5: too many SIV components " + strconv.Itoa(int(c))
+}
+
+type TweakSizeError int
+
+func (t TweakSizeError) Error() string {
+	return "scorpioncompute.com/rc5: invalid tweak size " + strconv.Itoa(int(t))
+}
+
+type PaddingMethodError int
+
+func (p PaddingMethodError) Error() string {
+	return "scorpioncompute.com/rc5: unsupported padding method " + strconv.Itoa(int(p))
+}
+
+type IntegrityError struct{}
+
+func (i IntegrityError) Error() string {
+	return "scorpioncompute.com/rc5: key unwrap integrity check failed"
+}
+
+type TokenError string
+
+func (t TokenError) Error() string {
+	return "scorpioncompute.com/rc5: invalid token " + strconv.Quote(string(t))
+}
+
+type DomainSizeError string
+
+func (d DomainSizeError) Error() string {
+	return "scorpioncompute.com/rc5: invalid domain size " + string(d)
+}
+
+type RadixError int
+
+func (r RadixError) Error() string {
+	return "scorpioncompute.com/rc5: unsupported radix " + strconv.Itoa(int(r))
+}
+
+type NumeralError string
+
+func (n NumeralError) Error() string {
+	return "scorpioncompute.com/rc5: invalid numeral string " + strconv.Quote(string(n))
+}
+
+type UUIDError string
+
+func (u UUIDError) Error() string {
+	return "scorpioncompute.com/rc5: invalid UUID " + strconv.Quote(string(u))
+}
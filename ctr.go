@@ -0,0 +1,84 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"math"
+)
+
+// CTR is a counter mode Stream that refuses to wrap its counter. Each
+// counter block is the nonce followed by a big-endian block counter that
+// starts at zero and fills the rest of the block, so a nonce of n bytes on a
+// block of BB bytes leaves 2^(8(BB - n)) blocks of keystream.
+type CTR struct {
+	b 				cipher.Block 	// block cipher
+	ctr 			[]byte 			// nonce and counter of the next block
+	out 			[]byte 			// keystream of the last partial block
+	outUsed 		int 			// bytes of out already consumed
+	next 			uint64 			// index of the next keystream block
+	limit 			uint64 			// number of counter values
+}
+
+// NewCTR returns a CTR for the given Block. The nonce must leave at least
+// one byte of the block for the counter.
+func NewCTR(b cipher.Block, nonce []byte) (*CTR, error) {
+	bs := b.BlockSize()
+	if len(nonce) >= bs {
+		return nil, NonceSizeError(len(nonce))
+	}
+
+	// with eight or more counter bytes the limit is beyond reach
+	limit := uint64(math.MaxUint64)
+	if n := bs - len(nonce); n < 8 {
+		limit = 1 << (8 * uint(n))
+	}
+
+	x := CTR{
+		b: b,
+		ctr: make([]byte, bs),
+		out: make([]byte, bs),
+		outUsed: bs,
+		limit: limit,
+	}
+	copy(x.ctr, nonce)
+	return &x, nil
+}
+
+// Available returns the number of bytes of keystream left, saturating at
+// math.MaxUint64.
+func (x *CTR) Available() uint64 {
+	bs := uint64(len(x.ctr))
+	left := uint64(len(x.out) - x.outUsed)
+	blocks := x.limit - x.next
+	if blocks > (math.MaxUint64 - left) / bs {
+		return math.MaxUint64
+	}
+	return blocks * bs + left
+}
+
+// XORKeyStream XORs each byte in src with a byte from the keystream. It
+// panics, leaving dst untouched, if src is longer than Available, since
+// going on would repeat keystream.
+func (x *CTR) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+	if uint64(len(src)) > x.Available() {
+		panic("rc5: CTR counter exhausted")
+	}
+
+	for len(src) > 0 {
+		if x.outUsed == len(x.out) {
+			x.b.Encrypt(x.out, x.ctr)
+			addCounter(x.ctr, 1)
+			x.next++
+			x.outUsed = 0
+		}
+		n := xorBytes(dst, src, x.out[x.outUsed:])
+		x.outUsed += n
+		dst, src = dst[n:], src[n:]
+	}
+}
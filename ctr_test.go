@@ -0,0 +1,84 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"math/rand"
+	"testing"
+)
+
+func TestCTR(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{8, 16, 32, 64, 128, 256} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		// one counter byte: 256 blocks of keystream
+		nonce := make([]byte, bs - 1)
+		random.Read(nonce)
+		x, err := NewCTR(block, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		size := 256 * bs
+		if available := x.Available(); available != uint64(size) {
+			t.Errorf("Available(%d) == %d, want %d", wordSize, available, size)
+		}
+
+		value := make([]byte, size)
+		random.Read(value)
+		expected := make([]byte, size)
+		iv := append(append([]byte{}, nonce...), 0)
+		cipher.NewCTR(block, iv).XORKeyStream(expected, value)
+
+		encrypted := make([]byte, size)
+		for n := 0; n < size - 1; {
+			m := n + random.Intn(3 * bs)
+			if m > size - 1 {
+				m = size - 1
+			}
+			x.XORKeyStream(encrypted[n:m], value[n:m])
+			n = m
+		}
+
+		// two bytes where only one is left
+		last := []byte{0xaa, 0xaa}
+		if !panics(func() { x.XORKeyStream(last, []byte{0, 0}) }) {
+			t.Errorf("CTR(%d) did not panic before wrapping", wordSize)
+		}
+		if !bytes.Equal(last, []byte{0xaa, 0xaa}) {
+			t.Errorf("CTR(%d) wrote output before panicking", wordSize)
+		}
+
+		x.XORKeyStream(encrypted[size - 1:], value[size - 1:])
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("CTR(%d) differs from cipher.NewCTR", wordSize)
+		}
+		if !panics(func() { x.XORKeyStream(last[:1], last[:1]) }) {
+			t.Errorf("CTR(%d) did not panic when exhausted", wordSize)
+		}
+	}
+}
+
+func TestCTRNonceSize(t *testing.T) {
+	block, _ := NewCipher16(make([]byte, 16), 12)
+	if _, err := NewCTR(block, make([]byte, 4)); err != NonceSizeError(4) {
+		t.Errorf("NewCTR error == %v, want %v", err, NonceSizeError(4))
+	}
+}
+
+func panics(f func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	f()
+	return false
+}
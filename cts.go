@@ -0,0 +1,150 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// CTSEncrypter encrypts whole messages with RC5-CTS, the ciphertext stealing
+// mode of RFC 2040 section 9. Ciphertext is exactly as long as the plaintext.
+type CTSEncrypter struct {
+	b 				cipher.Block 	// block cipher
+	iv 				[]byte 			// initialization vector
+}
+
+// NewCTSEncrypter returns a CTSEncrypter for the given Block. The length of
+// iv must be the same as the Block's block size.
+func NewCTSEncrypter(b cipher.Block, iv []byte) *CTSEncrypter {
+	if len(iv) != b.BlockSize() {
+		panic("rc5.NewCTSEncrypter: IV length must equal block size")
+	}
+	x := CTSEncrypter{b, make([]byte, len(iv))}
+	copy(x.iv, iv)
+	return &x
+}
+
+// Crypt encrypts the message src into dst, which may be src. It returns an
+// InputSizeError if src is shorter than one block.
+func (x *CTSEncrypter) Crypt(dst, src []byte) error {
+	bs := len(x.iv)
+	if len(src) < bs {
+		return InputSizeError(len(src))
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	n := (len(src) + bs - 1) / bs
+	if n == 1 {
+		NewCBCEncrypter(x.b, x.iv).CryptBlocks(dst, src)
+		return nil
+	}
+
+	head := (n - 2) * bs
+	Ln := len(src) - head - bs
+
+	// C1 ... Cn-2 are plain RC5-CBC
+	NewCBCEncrypter(x.b, x.iv).CryptBlocks(dst[:head], src[:head])
+	chain := x.iv
+	if head > 0 {
+		chain = dst[head - bs:head]
+	}
+
+	// En-1 = E(Pn-1 ^ Cn-2)
+	E := make([]byte, bs)
+	xorBytes(E, src[head:head + bs], chain)
+	x.b.Encrypt(E, E)
+
+	// Dn = En-1 ^ (Pn padded with zeros), Cn-1 = E(Dn)
+	D := make([]byte, bs)
+	copy(D, E)
+	xorBytes(D, D, src[head + bs:])
+	x.b.Encrypt(D, D)
+
+	// Cn is the first Ln bytes of En-1
+	copy(dst[head:head + bs], D)
+	copy(dst[head + bs:len(src)], E[:Ln])
+	return nil
+}
+
+// SetIV sets the IV used for the following messages.
+func (x *CTSEncrypter) SetIV(iv []byte) {
+	if len(iv) != len(x.iv) {
+		panic("rc5: incorrect length IV")
+	}
+	copy(x.iv, iv)
+}
+
+// CTSDecrypter decrypts whole messages encrypted with RC5-CTS.
+type CTSDecrypter struct {
+	b 				cipher.Block 	// block cipher
+	iv 				[]byte 			// initialization vector
+}
+
+// NewCTSDecrypter returns a CTSDecrypter for the given Block. The length of
+// iv must be the same as the Block's block size.
+func NewCTSDecrypter(b cipher.Block, iv []byte) *CTSDecrypter {
+	if len(iv) != b.BlockSize() {
+		panic("rc5.NewCTSDecrypter: IV length must equal block size")
+	}
+	x := CTSDecrypter{b, make([]byte, len(iv))}
+	copy(x.iv, iv)
+	return &x
+}
+
+// Crypt decrypts the message src into dst, which may be src. It returns an
+// InputSizeError if src is shorter than one block.
+func (x *CTSDecrypter) Crypt(dst, src []byte) error {
+	bs := len(x.iv)
+	if len(src) < bs {
+		return InputSizeError(len(src))
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	n := (len(src) + bs - 1) / bs
+	if n == 1 {
+		NewCBCDecrypter(x.b, x.iv).CryptBlocks(dst, src)
+		return nil
+	}
+
+	head := (n - 2) * bs
+	Ln := len(src) - head - bs
+
+	// take Cn-2, Cn-1 and Cn before an in-place decryption overwrites them
+	chain := make([]byte, bs)
+	copy(chain, x.iv)
+	if head > 0 {
+		copy(chain, src[head - bs:head])
+	}
+	D := make([]byte, bs)
+	copy(D, src[head:head + bs])
+	Cn := make([]byte, Ln)
+	copy(Cn, src[head + bs:])
+
+	NewCBCDecrypter(x.b, x.iv).CryptBlocks(dst[:head], src[:head])
+
+	// Dn = D(Cn-1), Pn = Dn ^ (Cn padded with zeros)
+	x.b.Decrypt(D, D)
+	Pn := make([]byte, Ln)
+	xorBytes(Pn, D, Cn)
+
+	// En-1 = Cn followed by the tail of Dn, Pn-1 = D(En-1) ^ Cn-2
+	copy(D, Cn)
+	x.b.Decrypt(D, D)
+	xorBytes(dst[head:head + bs], D, chain)
+	copy(dst[head + bs:len(src)], Pn)
+	return nil
+}
+
+// SetIV sets the IV used for the following messages.
+func (x *CTSDecrypter) SetIV(iv []byte) {
+	if len(iv) != len(x.iv) {
+		panic("rc5: incorrect length IV")
+	}
+	copy(x.iv, iv)
+}
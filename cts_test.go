@@ -0,0 +1,79 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestCTS(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		iv := make([]byte, bs)
+		random.Read(iv)
+		e := NewCTSEncrypter(block, iv)
+		d := NewCTSDecrypter(block, iv)
+
+		for size := bs; size <= 5 * bs; size++ {
+			value := make([]byte, size)
+			random.Read(value)
+
+			encrypted := make([]byte, size)
+			if err := e.Crypt(encrypted, value); err != nil {
+				t.Fatal(err)
+			}
+
+			// the RFC 2040 layout: CBC up to and including En-1, whose
+			// first Ln bytes become Cn, and Cn-1 = E(En-1 ^ Pn||0...)
+			if size > bs {
+				head := ((size + bs - 1) / bs - 2) * bs
+				cbc := make([]byte, head + bs)
+				NewCBCEncrypter(block, iv).CryptBlocks(cbc, value[:head + bs])
+				Ln := size - head - bs
+
+				last := make([]byte, bs)
+				xorBytes(last, cbc[head:], value[head + bs:])
+				copy(last[Ln:], cbc[head + Ln:])
+				block.Encrypt(last, last)
+
+				if !bytes.Equal(encrypted[:head], cbc[:head]) ||
+					!bytes.Equal(encrypted[head:head + bs], last) ||
+					!bytes.Equal(encrypted[head + bs:], cbc[head:head + Ln]) {
+					t.Errorf("encrypt(%d, %d bytes) layout differs from RFC 2040", wordSize, size)
+				}
+			}
+
+			decrypted := make([]byte, size)
+			copy(decrypted, encrypted)
+			if err := d.Crypt(decrypted, decrypted); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decrypted, value) {
+				t.Errorf("decrypt(%d, %d bytes) failed: % 02x != % 02x", wordSize, size, decrypted, value)
+			}
+		}
+	}
+}
+
+func TestCTSShortInput(t *testing.T) {
+	block, _ := NewCipher32(make([]byte, 16), 12)
+	iv := make([]byte, 8)
+	buf := make([]byte, 7)
+
+	if err := NewCTSEncrypter(block, iv).Crypt(buf, buf); err != InputSizeError(7) {
+		t.Errorf("encrypt of 7 bytes error == %v, want %v", err, InputSizeError(7))
+	}
+	if err := NewCTSDecrypter(block, iv).Crypt(buf, buf); err != InputSizeError(7) {
+		t.Errorf("decrypt of 7 bytes error == %v, want %v", err, InputSizeError(7))
+	}
+}
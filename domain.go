@@ -0,0 +1,146 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math/big"
+)
+
+// DomainPermutation is a keyed bijection on [0, N) for any N of at least
+// one: RC5 with the smallest whole-byte word size whose block holds N - 1,
+// cycle walked, encrypting again until the result falls inside the domain.
+// A block holds fewer than 2^17 times N values, and on average walking
+// takes that ratio in encryptions, so domains just past a block size are
+// slowest.
+type DomainPermutation struct {
+	b 				cipher.Block 	// RC5-W cipher
+	n 				*big.Int 		// domain size N
+	max 			uint64 			// N - 1, when it fits
+	fits 			bool 			// whether N - 1 fits in a uint64
+	w 				uint 			// word size in bits
+}
+
+// NewDomainPermutation returns a permutation of [0, n) under key with the
+// given number of rounds.
+func NewDomainPermutation(key []byte, rounds uint, n *big.Int) (*DomainPermutation, error) {
+	if n.Sign() < 1 {
+		return nil, DomainSizeError(n.String())
+	}
+
+	// two words of w bits must hold N - 1
+	max := new(big.Int).Sub(n, one)
+	w := uint(8 * ((max.BitLen() + 15) / 16))
+	if w < 8 {
+		w = 8
+	}
+
+	b, err := NewCipher(key, rounds, w)
+	if err != nil {
+		return nil, err
+	}
+	return &DomainPermutation{
+		b: b,
+		n: new(big.Int).Set(n),
+		max: max.Uint64(),
+		fits: max.IsUint64(),
+		w: w,
+	}, nil
+}
+
+// NewDomainPermutationUint64 is NewDomainPermutation for n of at most
+// 2^64 - 1.
+func NewDomainPermutationUint64(key []byte, rounds uint, n uint64) (*DomainPermutation, error) {
+	return NewDomainPermutation(key, rounds, new(big.Int).SetUint64(n))
+}
+
+// WordSize returns the RC5 word size in bits.
+func (d *DomainPermutation) WordSize() uint { return d.w }
+
+// Encrypt returns the image of x, which must be in the domain. The domain
+// must fit in a uint64.
+func (d *DomainPermutation) Encrypt(x uint64) uint64 {
+	return d.walk(x, true)
+}
+
+// Decrypt inverts Encrypt.
+func (d *DomainPermutation) Decrypt(x uint64) uint64 {
+	return d.walk(x, false)
+}
+
+func (d *DomainPermutation) walk(x uint64, encrypt bool) uint64 {
+	if !d.fits || x > d.max {
+		panic("rc5: value outside the permutation's domain")
+	}
+
+	// the 32- and 64-bit blocks walk without touching bytes
+	var buf [16]byte
+	bs := d.b.BlockSize()
+	for {
+		switch c := d.b.(type) {
+		case *cipher16:
+			if encrypt {
+				x = uint64(PermuteUint32(c, uint32(x)))
+			} else {
+				x = uint64(UnpermuteUint32(c, uint32(x)))
+			}
+		case *cipher32:
+			if encrypt {
+				x = PermuteUint64(c, x)
+			} else {
+				x = UnpermuteUint64(c, x)
+			}
+		default:
+			binary.LittleEndian.PutUint64(buf[:], x)
+			if encrypt {
+				d.b.Encrypt(buf[:bs], buf[:bs])
+			} else {
+				d.b.Decrypt(buf[:bs], buf[:bs])
+			}
+			x = binary.LittleEndian.Uint64(buf[:])
+		}
+
+		if x <= d.max {
+			return x
+		}
+	}
+}
+
+// EncryptBig returns the image of x, which must be in the domain.
+func (d *DomainPermutation) EncryptBig(x *big.Int) *big.Int {
+	return d.walkBig(x, true)
+}
+
+// DecryptBig inverts EncryptBig.
+func (d *DomainPermutation) DecryptBig(x *big.Int) *big.Int {
+	return d.walkBig(x, false)
+}
+
+func (d *DomainPermutation) walkBig(x *big.Int, encrypt bool) *big.Int {
+	if x.Sign() < 0 || x.Cmp(d.n) >= 0 {
+		panic("rc5: value outside the permutation's domain")
+	}
+	if d.fits {
+		return new(big.Int).SetUint64(d.walk(x.Uint64(), encrypt))
+	}
+
+	// blocks hold their value little-endian, as RC5 reads its words
+	buf := make([]byte, d.b.BlockSize())
+	y := new(big.Int).Set(x)
+	for {
+		reverse(y.FillBytes(buf))
+		if encrypt {
+			d.b.Encrypt(buf, buf)
+		} else {
+			d.b.Decrypt(buf, buf)
+		}
+		y.SetBytes(reverse(buf))
+
+		if y.Cmp(d.n) < 0 {
+			return y
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestDomainWordSize(t *testing.T) {
+	for _, v := range []struct {
+		n 				string
+		w 				uint
+	}{
+		{"1", 8},
+		{"100", 8},
+		{"65536", 8},
+		{"65537", 16},
+		{"1000000000", 16},
+		{"2176782336", 16}, 	// 36^6
+		{"4294967297", 24},
+		{"18446744073709551616", 32}, 	// 2^64
+		{"18446744073709551617", 40},
+	} {
+		n, _ := new(big.Int).SetString(v.n, 10)
+		d, err := NewDomainPermutation(seqBytes(16), 12, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.WordSize() != v.w {
+			t.Errorf("domain of %s uses RC5-%d, want RC5-%d", v.n, d.WordSize(), v.w)
+		}
+	}
+
+	for _, n := range []int64{0, -1} {
+		if _, err := NewDomainPermutation(nil, 12, big.NewInt(n)); err != DomainSizeError(big.NewInt(n).String()) {
+			t.Errorf("NewDomainPermutation(%d) error == %v, want DomainSizeError", n, err)
+		}
+	}
+}
+
+func TestDomainBijective(t *testing.T) {
+	for _, n := range []uint64{1, 2, 7, 100, 1296, 65536} {
+		d, _ := NewDomainPermutationUint64(seqBytes(16), 12, n)
+
+		seen := make([]bool, n)
+		fixed := 0
+		for x := uint64(0); x < n; x++ {
+			y := d.Encrypt(x)
+			if y >= n {
+				t.Fatalf("domain %d: Encrypt(%d) == %d is outside the domain", n, x, y)
+			}
+			if seen[y] {
+				t.Fatalf("domain %d: Encrypt(%d) == %d repeats an output", n, x, y)
+			}
+			seen[y] = true
+			if y == x {
+				fixed++
+			}
+			if back := d.Decrypt(y); back != x {
+				t.Errorf("domain %d: Decrypt(Encrypt(%d)) == %d", n, x, back)
+			}
+		}
+
+		// a random permutation fixes about one point
+		if n > 100 && fixed > 10 {
+			t.Errorf("domain %d: %d fixed points", n, fixed)
+		}
+	}
+}
+
+func TestDomainBig(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, s := range []string{"1000000000", "2176782336", "18446744073709551615", "1000000000000000000000000000000"} {
+		n, _ := new(big.Int).SetString(s, 10)
+		d, _ := NewDomainPermutation(seqBytes(16), 12, n)
+
+		for i := 0; i < 20; i++ {
+			x := new(big.Int).Rand(random, n)
+			y := d.EncryptBig(x)
+			if y.Sign() < 0 || y.Cmp(n) >= 0 {
+				t.Errorf("domain %s: EncryptBig(%s) == %s is outside the domain", s, x, y)
+			}
+			if back := d.DecryptBig(y); back.Cmp(x) != 0 {
+				t.Errorf("domain %s: DecryptBig(EncryptBig(%s)) == %s", s, x, back)
+			}
+			if x.IsUint64() && d.Encrypt(x.Uint64()) != y.Uint64() {
+				t.Errorf("domain %s: Encrypt(%s) differs from EncryptBig", s, x)
+			}
+		}
+	}
+
+	d, _ := NewDomainPermutationUint64(seqBytes(16), 12, 100)
+	if !panics(func() { d.Encrypt(100) }) {
+		t.Error("Encrypt accepted a value outside the domain")
+	}
+	if !panics(func() { d.EncryptBig(big.NewInt(-1)) }) {
+		t.Error("EncryptBig accepted a negative value")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+type eax struct {
+	b 				cipher.Block 	// block cipher
+	nonceSize 		int 			// nonce size in bytes
+	tagSize 		int 			// tag size in bytes
+	k1 				[]byte 			// OMAC subkey for whole final blocks
+	k2 				[]byte 			// OMAC subkey for padded final blocks
+}
+
+// NewEAX returns the EAX authenticated encryption mode of Bellare, Rogaway
+// and Wagner over the given Block, which may have any block size with a
+// doubling polynomial: 16 to 1024 bits, covering every RC5 word size from 8
+// to 512. Tags are tagSize bytes, at most one block.
+func NewEAX(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	bs := b.BlockSize()
+	if !hasReduction(bs) {
+		return nil, BlockSizeError(bs)
+	}
+	if tagSize < 1 || tagSize > bs {
+		return nil, TagSizeError(tagSize)
+	}
+	if nonceSize < 1 {
+		return nil, NonceSizeError(nonceSize)
+	}
+
+	k1, k2 := cmacSubkeys(b)
+	return &eax{b, nonceSize, tagSize, k1, k2}, nil
+}
+
+func (e *eax) NonceSize() int { return e.nonceSize }
+
+func (e *eax) Overhead() int { return e.tagSize }
+
+func (e *eax) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != e.nonceSize {
+		panic("rc5: incorrect nonce length given to EAX")
+	}
+
+	N := e.omacT(0, nonce)
+	H := e.omacT(1, additionalData)
+
+	ret, out := sliceForAppend(dst, len(plaintext) + e.tagSize)
+	ciphertext := out[:len(plaintext)]
+	cipher.NewCTR(e.b, N).XORKeyStream(ciphertext, plaintext)
+
+	C := e.omacT(2, ciphertext)
+	for i := 0; i < e.tagSize; i++ {
+		out[len(plaintext) + i] = N[i] ^ H[i] ^ C[i]
+	}
+	return ret
+}
+
+func (e *eax) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != e.nonceSize {
+		panic("rc5: incorrect nonce length given to EAX")
+	}
+	if len(ciphertext) < e.tagSize {
+		return nil, AuthenticationError{}
+	}
+
+	tag := ciphertext[len(ciphertext) - e.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext) - e.tagSize]
+
+	N := e.omacT(0, nonce)
+	H := e.omacT(1, additionalData)
+	C := e.omacT(2, ciphertext)
+	for i := 0; i < e.tagSize; i++ {
+		C[i] ^= N[i] ^ H[i]
+	}
+	if subtle.ConstantTimeCompare(C[:e.tagSize], tag) != 1 {
+		return nil, AuthenticationError{}
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	cipher.NewCTR(e.b, N).XORKeyStream(out, ciphertext)
+	return ret, nil
+}
+
+// omacT is OMAC of the block [t]_n followed by data.
+func (e *eax) omacT(t byte, data []byte) []byte {
+	bs := e.b.BlockSize()
+	mac := make([]byte, bs)
+	mac[bs - 1] = t
+	if len(data) == 0 {
+		xorBytes(mac, mac, e.k1)
+		e.b.Encrypt(mac, mac)
+		return mac
+	}
+
+	e.b.Encrypt(mac, mac)
+	cmacUpdate(e.b, e.k1, e.k2, mac, data)
+	return mac
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and
+// a second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
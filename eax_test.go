@@ -0,0 +1,122 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// AES-128 vectors from the EAX paper, checking the mode itself.
+var eaxVectors = []struct {
+	key 			string
+	nonce 			string
+	header 			string
+	msg 			string
+	cipher 			string
+}{
+	{"233952dee4d5ed5f9b9c6d6ff80ff478", "62ec67f9c3a4a407fcb2a8c49031a8b3", "6bfb914fd07eae6b",
+		"", "e037830e8389f27b025a2d6527e79d01"},
+	{"91945d3f4dcbee0bf45ef52255f095a4", "becaf043b0a23d843194ba972c66debd", "fa3bfd4806eb53fa",
+		"f7fb", "19dd5c4c9331049d0bdab0277408f67967e5"},
+}
+
+func TestEAXVectors(t *testing.T) {
+	for n, v := range eaxVectors {
+		key, _ := hex.DecodeString(v.key)
+		nonce, _ := hex.DecodeString(v.nonce)
+		header, _ := hex.DecodeString(v.header)
+		msg, _ := hex.DecodeString(v.msg)
+		expected, _ := hex.DecodeString(v.cipher)
+
+		block, _ := aes.NewCipher(key)
+		aead, _ := NewEAX(block, len(nonce), 16)
+
+		if sealed := aead.Seal(nil, nonce, msg, header); !bytes.Equal(sealed, expected) {
+			t.Errorf("vector %d: Seal == % 02x, want % 02x", n + 1, sealed, expected)
+		}
+		if opened, err := aead.Open(nil, nonce, expected, header); err != nil || !bytes.Equal(opened, msg) {
+			t.Errorf("vector %d: Open == % 02x, %v, want % 02x", n + 1, opened, err, msg)
+		}
+	}
+}
+
+func TestEAX(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{8, 16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+
+		for _, sizes := range [][2]int{{12, block.BlockSize()}, {1, 1}, {16, 4}} {
+			if sizes[1] > block.BlockSize() {
+				continue
+			}
+			aead, err := NewEAX(block, sizes[0], sizes[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for size := 0; size < 100; size += 7 {
+				nonce := make([]byte, aead.NonceSize())
+				random.Read(nonce)
+				ad := make([]byte, random.Intn(40))
+				random.Read(ad)
+				value := make([]byte, size)
+				random.Read(value)
+
+				sealed := aead.Seal(nil, nonce, value, ad)
+				if len(sealed) != size + aead.Overhead() {
+					t.Errorf("Seal(%d) length == %d, want %d", wordSize, len(sealed), size + aead.Overhead())
+				}
+
+				opened, err := aead.Open(nil, nonce, sealed, ad)
+				if err != nil || !bytes.Equal(opened, value) {
+					t.Errorf("Open(%d, %d bytes) failed: %v", wordSize, size, err)
+				}
+
+				// any flipped bit of ciphertext, tag, data or nonce must be caught,
+				// though a one byte tag is forged by chance one time in 256
+				if aead.Overhead() < 4 {
+					continue
+				}
+				sealed[random.Intn(len(sealed))] ^= 1 << uint(random.Intn(8))
+				if _, err := aead.Open(nil, nonce, sealed, ad); err != (AuthenticationError{}) {
+					t.Errorf("Open(%d) of tampered ciphertext error == %v", wordSize, err)
+				}
+				sealed = aead.Seal(sealed[:0], nonce, value, ad)
+
+				i := random.Intn(len(nonce))
+				nonce[i] ^= 1
+				if _, err := aead.Open(nil, nonce, sealed, ad); err != (AuthenticationError{}) {
+					t.Errorf("Open(%d) with tampered nonce error == %v", wordSize, err)
+				}
+				nonce[i] ^= 1
+				if len(ad) > 0 {
+					ad[0] ^= 0x80
+					if _, err := aead.Open(nil, nonce, sealed, ad); err != (AuthenticationError{}) {
+						t.Errorf("Open(%d) with tampered data error == %v", wordSize, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEAXErrors(t *testing.T) {
+	block, _ := NewCipher32(make([]byte, 16), 12)
+	if _, err := NewEAX(block, 8, 9); err != TagSizeError(9) {
+		t.Errorf("NewEAX error == %v, want %v", err, TagSizeError(9))
+	}
+
+	odd, _ := NewCipher(make([]byte, 16), 12, 24)
+	if _, err := NewEAX(odd, 8, 4); err != BlockSizeError(6) {
+		t.Errorf("NewEAX error == %v, want %v", err, BlockSizeError(6))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// EME is the wide-block, tweakable encrypt-mix-encrypt mode of Halevi and
+// Rogaway over a block of at least 128 bits, such as RC5-64 or a big word
+// RC5. A sector is enciphered as a whole: changing any bit of the sector or
+// the tweak changes every block of the result. Sectors are 1 to n blocks
+// long, n being the block size in bits, so up to 2 KiB for RC5-64 and 8 KiB
+// for RC5-128. Multiplication by 2 uses the same GF(2^n) doubling as CMAC.
+type EME struct {
+	b 				cipher.Block 	// block cipher
+	L 				[][]byte 		// 2^j L for j < n, L = 2 E(0)
+}
+
+// NewEME returns an EME over the given Block.
+func NewEME(b cipher.Block) (*EME, error) {
+	bs := b.BlockSize()
+	if bs < 16 || !hasReduction(bs) {
+		return nil, BlockSizeError(bs)
+	}
+
+	L := make([][]byte, 8 * bs)
+	prev := make([]byte, bs)
+	b.Encrypt(prev, prev)
+	for j := range L {
+		L[j] = make([]byte, bs)
+		double(L[j], prev)
+		prev = L[j]
+	}
+	return &EME{b, L}, nil
+}
+
+// Encrypt enciphers the sector src under tweak, one block long, into dst,
+// which may be src. It returns an InputSizeError if src is not 1 to n whole
+// blocks.
+func (e *EME) Encrypt(dst, tweak, src []byte) error {
+	return e.transform(dst, tweak, src, e.b.Encrypt)
+}
+
+// Decrypt reverses Encrypt.
+func (e *EME) Decrypt(dst, tweak, src []byte) error {
+	return e.transform(dst, tweak, src, e.b.Decrypt)
+}
+
+// transform is EME in either direction, the two being mirror images with
+// the block cipher replaced by its inverse.
+func (e *EME) transform(dst, tweak, src []byte, f func(dst, src []byte)) error {
+	bs := e.b.BlockSize()
+	m := len(src) / bs
+	if len(src) % bs != 0 || m < 1 || m > len(e.L) {
+		return InputSizeError(len(src))
+	}
+	if len(tweak) != bs {
+		return TweakSizeError(len(tweak))
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	// PPP_j = f(2^(j-1) L ^ P_j)
+	ppp := make([]byte, len(src))
+	for j := 0; j < m; j++ {
+		block := ppp[j * bs:(j + 1) * bs]
+		xorBytes(block, src[j * bs:], e.L[j])
+		f(block, block)
+	}
+
+	// MP = PPP_1 ^ PPP_2 ^ ... ^ PPP_m ^ T, MC = f(MP), M = MP ^ MC
+	mp := make([]byte, bs)
+	copy(mp, tweak)
+	for j := 0; j < m; j++ {
+		xorBytes(mp, mp, ppp[j * bs:])
+	}
+	mc := make([]byte, bs)
+	f(mc, mp)
+	M := make([]byte, bs)
+	xorBytes(M, mp, mc)
+
+	// CCC_j = 2^(j-1) M ^ PPP_j for j > 1, CCC_1 = MC ^ CCC_2 ^ ... ^ T
+	ccc1 := ppp[:bs]
+	copy(ccc1, mc)
+	xorBytes(ccc1, ccc1, tweak)
+	for j := 1; j < m; j++ {
+		block := ppp[j * bs:(j + 1) * bs]
+		double(M, M)
+		xorBytes(block, block, M)
+		xorBytes(ccc1, ccc1, block)
+	}
+
+	// C_j = f(CCC_j) ^ 2^(j-1) L
+	for j := 0; j < m; j++ {
+		block := ppp[j * bs:(j + 1) * bs]
+		f(block, block)
+		xorBytes(dst[j * bs:], block, e.L[j])
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// changedBlocks counts the blocks of size bs that differ between a and b.
+func changedBlocks(a, b []byte, bs int) int {
+	n := 0
+	for i := 0; i < len(a); i += bs {
+		if !bytes.Equal(a[i:i + bs], b[i:i + bs]) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestEME(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		e, err := NewEME(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, m := range []int{1, 2, 3, 17, 8 * bs} {
+			tweak := make([]byte, bs)
+			random.Read(tweak)
+			value := make([]byte, m * bs)
+			random.Read(value)
+
+			encrypted := make([]byte, len(value))
+			if err := e.Encrypt(encrypted, tweak, value); err != nil {
+				t.Fatal(err)
+			}
+
+			decrypted := make([]byte, len(value))
+			copy(decrypted, encrypted)
+			if err := e.Decrypt(decrypted, tweak, decrypted); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decrypted, value) {
+				t.Errorf("EME(%d, %d blocks) round trip failed", wordSize, m)
+			}
+
+			// one flipped bit of the sector or the tweak changes every block
+			flipped := make([]byte, len(value))
+			copy(flipped, value)
+			flipped[random.Intn(len(flipped))] ^= 1 << uint(random.Intn(8))
+			other := make([]byte, len(value))
+			e.Encrypt(other, tweak, flipped)
+			if n := changedBlocks(encrypted, other, bs); n != m {
+				t.Errorf("EME(%d, %d blocks): a flipped sector bit changed %d blocks", wordSize, m, n)
+			}
+
+			tweak[random.Intn(bs)] ^= 1 << uint(random.Intn(8))
+			e.Encrypt(other, tweak, value)
+			if n := changedBlocks(encrypted, other, bs); n != m {
+				t.Errorf("EME(%d, %d blocks): a flipped tweak bit changed %d blocks", wordSize, m, n)
+			}
+		}
+	}
+}
+
+func TestEMEErrors(t *testing.T) {
+	block, _ := NewCipher64(make([]byte, 16), 12)
+	e, _ := NewEME(block)
+	tweak := make([]byte, 16)
+
+	for _, size := range []int{0, 15, 17, 129 * 16} {
+		buf := make([]byte, size)
+		if err := e.Encrypt(buf, tweak, buf); err != InputSizeError(size) {
+			t.Errorf("Encrypt of %d bytes error == %v, want %v", size, err, InputSizeError(size))
+		}
+	}
+
+	buf := make([]byte, 32)
+	if err := e.Encrypt(buf, tweak[:8], buf); err != TweakSizeError(8) {
+		t.Errorf("Encrypt error == %v, want %v", err, TweakSizeError(8))
+	}
+
+	small, _ := NewCipher32(make([]byte, 16), 12)
+	if _, err := NewEME(small); err != BlockSizeError(8) {
+		t.Errorf("NewEME error == %v, want %v", err, BlockSizeError(8))
+	}
+}
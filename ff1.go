@@ -0,0 +1,212 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+const (
+	ff1BlockSize 		= 16 		// FF1 is defined over 128-bit blocks
+	ff1Rounds 			= 10 		// Feistel rounds
+	ff1MinDomain 		= 1000000 	// smallest radix^minLen SP 800-38G allows
+)
+
+// FF1 is the format-preserving encryption mode of NIST SP 800-38G over a
+// 128-bit block such as RC5-64: a ten round Feistel network on strings of
+// numerals, so a string of digits encrypts to digits of the same length.
+type FF1 struct {
+	b 				cipher.Block 	// block cipher for the CBC-MAC PRF
+	radix 			int 			// number of numerals
+	alphabet 		[]rune 			// character of each numeral
+	numerals 		map[rune]uint16 // numeral of each character
+	minLen 			int 			// shortest string accepted
+	maxLen 			int 			// longest string accepted
+	tweak 			[]byte 			// default tweak
+}
+
+// NewFF1 returns FF1 over b for strings of minLen to maxLen characters in
+// radix 2 to 62, written with the first radix characters of 0-9, A-Z, a-z:
+// radix 10 for digits and 36 or 62 for alphanumeric strings. tweak is used
+// by Encrypt and Decrypt. radix^minLen must be at least one million.
+func NewFF1(b cipher.Block, radix, minLen, maxLen int, tweak []byte) (*FF1, error) {
+	if radix < 2 || radix > len(base62) {
+		return nil, RadixError(radix)
+	}
+	return NewFF1WithAlphabet(b, base62[:radix], minLen, maxLen, tweak)
+}
+
+// NewFF1WithAlphabet is NewFF1 with the radix and the characters of the
+// numerals given by alphabet, which must not repeat a character.
+func NewFF1WithAlphabet(b cipher.Block, alphabet string, minLen, maxLen int, tweak []byte) (*FF1, error) {
+	if bs := b.BlockSize(); bs != ff1BlockSize {
+		return nil, BlockSizeError(bs)
+	}
+
+	f := FF1{
+		b: b,
+		alphabet: []rune(alphabet),
+		numerals: make(map[rune]uint16),
+		minLen: minLen,
+		maxLen: maxLen,
+		tweak: append([]byte(nil), tweak...),
+	}
+	for i, r := range f.alphabet {
+		if _, ok := f.numerals[r]; ok {
+			return nil, NumeralError(alphabet)
+		}
+		f.numerals[r] = uint16(i)
+	}
+
+	f.radix = len(f.alphabet)
+	if f.radix < 2 || f.radix > 1 << 16 {
+		return nil, RadixError(f.radix)
+	}
+	if minLen < 2 || maxLen < minLen || uint64(maxLen) >= 1 << 32 {
+		return nil, InputSizeError(minLen)
+	}
+	if float64(minLen) * math.Log2(float64(f.radix)) < math.Log2(ff1MinDomain) {
+		return nil, InputSizeError(minLen)
+	}
+	return &f, nil
+}
+
+// Encrypt encrypts x under the default tweak.
+func (f *FF1) Encrypt(x string) (string, error) {
+	return f.EncryptWithTweak(x, f.tweak)
+}
+
+// Decrypt decrypts x under the default tweak.
+func (f *FF1) Decrypt(x string) (string, error) {
+	return f.DecryptWithTweak(x, f.tweak)
+}
+
+// EncryptWithTweak encrypts x under tweak.
+func (f *FF1) EncryptWithTweak(x string, tweak []byte) (string, error) {
+	return f.cryptString(x, tweak, true)
+}
+
+// DecryptWithTweak decrypts x under tweak.
+func (f *FF1) DecryptWithTweak(x string, tweak []byte) (string, error) {
+	return f.cryptString(x, tweak, false)
+}
+
+func (f *FF1) cryptString(x string, tweak []byte, encrypt bool) (string, error) {
+	r := []rune(x)
+	if len(r) < f.minLen || len(r) > f.maxLen {
+		return "", InputSizeError(len(r))
+	}
+
+	X := make([]uint16, len(r))
+	for i, c := range r {
+		n, ok := f.numerals[c]
+		if !ok {
+			return "", NumeralError(x)
+		}
+		X[i] = n
+	}
+
+	Y := f.crypt(X, tweak, encrypt)
+	for i, n := range Y {
+		r[i] = f.alphabet[n]
+	}
+	return string(r), nil
+}
+
+// crypt is algorithm 7, FF1.Encrypt, or algorithm 8, FF1.Decrypt, of SP
+// 800-38G on the numerals X, which it does not change.
+func (f *FF1) crypt(X []uint16, T []byte, encrypt bool) []uint16 {
+	n := len(X)
+	u := n / 2
+	v := n - u
+	radix := big.NewInt(int64(f.radix))
+
+	// b bytes hold radix^v - 1, d bytes of PRF output are reduced
+	b := (new(big.Int).Sub(new(big.Int).Exp(radix, big.NewInt(int64(v)), nil), one).BitLen() + 7) / 8
+	d := 4 * ((b + 3) / 4) + 4
+
+	P := []byte{1, 2, 1, 0, 0, 0, 10, byte(u), 0, 0, 0, 0, 0, 0, 0, 0}
+	P[3], P[4], P[5] = byte(f.radix >> 16), byte(f.radix >> 8), byte(f.radix)
+	binary.BigEndian.PutUint32(P[8:], uint32(n))
+	binary.BigEndian.PutUint32(P[12:], uint32(len(T)))
+
+	// Q = T || 0^(-t-b-1 mod 16) || [i] || [NUM(B)]^b
+	Q := make([]byte, len(T) + (16 - (len(T) + b + 1) % 16) % 16 + 1 + b)
+	copy(Q, T)
+
+	mac, _ := NewCBCMAC(f.b, nil, ISO9797Padding1, ff1BlockSize)
+	S := make([]byte, (d + 15) / 16 * 16)
+
+	A := f.num(X[:u])
+	B := f.num(X[u:])
+	modU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+	y := new(big.Int)
+
+	for k := 0; k < ff1Rounds; k++ {
+		i, in := k, B
+		if !encrypt {
+			i, in = ff1Rounds - 1 - k, A
+		}
+
+		Q[len(Q) - b - 1] = byte(i)
+		in.FillBytes(Q[len(Q) - b:])
+
+		// S = R || CIPH(R ^ [1]^16) || CIPH(R ^ [2]^16) ...
+		mac.Reset()
+		mac.Write(P)
+		mac.Write(Q)
+		R := mac.Sum(S[:0])
+		for j := 1; j < len(S) / 16; j++ {
+			block := S[16 * j:16 * j + 16]
+			copy(block, R)
+			binary.BigEndian.PutUint64(block[8:], binary.BigEndian.Uint64(R[8:]) ^ uint64(j))
+			f.b.Encrypt(block, block)
+		}
+		y.SetBytes(S[:d])
+
+		mod := modU
+		if i % 2 == 1 {
+			mod = modV
+		}
+		if encrypt {
+			c := new(big.Int).Add(A, y)
+			A, B = B, c.Mod(c, mod)
+		} else {
+			c := new(big.Int).Sub(B, y)
+			B, A = A, c.Mod(c, mod)
+		}
+	}
+
+	Y := make([]uint16, n)
+	f.str(Y[:u], A)
+	f.str(Y[u:], B)
+	return Y
+}
+
+// num is NUM_radix(X), the numerals X most significant first.
+func (f *FF1) num(X []uint16) *big.Int {
+	radix := big.NewInt(int64(f.radix))
+	x := new(big.Int)
+	for _, n := range X {
+		x.Mul(x, radix)
+		x.Add(x, big.NewInt(int64(n)))
+	}
+	return x
+}
+
+// str sets X to STR^m_radix(x), where m is len(X).
+func (f *FF1) str(X []uint16, x *big.Int) {
+	radix := big.NewInt(int64(f.radix))
+	x = new(big.Int).Set(x)
+	r := new(big.Int)
+	for i := len(X) - 1; i >= 0; i-- {
+		x.QuoRem(x, radix, r)
+		X[i] = uint16(r.Int64())
+	}
+}
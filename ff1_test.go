@@ -0,0 +1,235 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const lowerBase36 = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+func TestFF1Vectors(t *testing.T) {
+	// AES FF1 samples 1 to 9 from NIST, checking the mode itself
+	const key = "2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94"
+	for n, v := range []struct {
+		keyLen 			int
+		radix 			int
+		tweak 			string
+		plain 			string
+		cipher 			string
+	}{
+		{16, 10, "", "0123456789", "2433477484"},
+		{16, 10, "39383736353433323130", "0123456789", "6124200773"},
+		{16, 36, "3737373770717273373737", "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{24, 10, "", "0123456789", "2830668132"},
+		{24, 10, "39383736353433323130", "0123456789", "2496655549"},
+		{24, 36, "3737373770717273373737", "0123456789abcdefghi", "xbj3kv35jrawxv32ysr"},
+		{32, 10, "", "0123456789", "6657667009"},
+		{32, 10, "39383736353433323130", "0123456789", "1001623463"},
+		{32, 36, "3737373770717273373737", "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+	} {
+		k, _ := hex.DecodeString(key[:2 * v.keyLen])
+		tweak, _ := hex.DecodeString(v.tweak)
+		block, _ := aes.NewCipher(k)
+
+		f, err := NewFF1WithAlphabet(block, lowerBase36[:v.radix], 6, 32, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted, err := f.Encrypt(v.plain); err != nil || encrypted != v.cipher {
+			t.Errorf("sample %d: encrypt == %q, %v, want %q", n + 1, encrypted, err, v.cipher)
+		}
+		if decrypted, err := f.Decrypt(v.cipher); err != nil || decrypted != v.plain {
+			t.Errorf("sample %d: decrypt == %q, %v, want %q", n + 1, decrypted, err, v.plain)
+		}
+	}
+}
+
+// ff1Reference is algorithm 7 of SP 800-38G as written, on numerals in
+// radix, with PRF the last block of the standard library's CBC from a zero IV.
+func ff1Reference(b cipher.Block, radix int, X []int, T []byte) []int {
+	n, t := len(X), len(T)
+	u := n / 2
+	v := n - u
+	A, B := X[:u], X[u:]
+	r := big.NewInt(int64(radix))
+
+	num := func(X []int) *big.Int {
+		x := new(big.Int)
+		for _, d := range X {
+			x.Mul(x, r).Add(x, big.NewInt(int64(d)))
+		}
+		return x
+	}
+	str := func(x *big.Int, m int) []int {
+		X := make([]int, m)
+		x = new(big.Int).Set(x)
+		d := new(big.Int)
+		for i := m - 1; i >= 0; i-- {
+			x.DivMod(x, r, d)
+			X[i] = int(d.Int64())
+		}
+		return X
+	}
+	bytesOf := func(x *big.Int, s int) []byte {
+		out := make([]byte, s)
+		xb := x.Bytes()
+		copy(out[s - len(xb):], xb)
+		return out
+	}
+
+	rv := new(big.Int).Exp(r, big.NewInt(int64(v)), nil)
+	bb := (rv.Sub(rv, big.NewInt(1)).BitLen() + 7) / 8
+	d := 4 * ((bb + 3) / 4) + 4
+
+	P := []byte{1, 2, 1}
+	P = append(P, bytesOf(r, 3)...)
+	P = append(P, 10, byte(u))
+	P = append(P, bytesOf(big.NewInt(int64(n)), 4)...)
+	P = append(P, bytesOf(big.NewInt(int64(t)), 4)...)
+
+	for i := 0; i < 10; i++ {
+		Q := append([]byte(nil), T...)
+		Q = append(Q, make([]byte, ((-t - bb - 1) % 16 + 16) % 16)...)
+		Q = append(Q, byte(i))
+		Q = append(Q, bytesOf(num(B), bb)...)
+
+		PQ := append(append([]byte(nil), P...), Q...)
+		cipher.NewCBCEncrypter(b, make([]byte, 16)).CryptBlocks(PQ, PQ)
+		R := PQ[len(PQ) - 16:]
+
+		S := append([]byte(nil), R...)
+		for j := 1; len(S) < d; j++ {
+			block := append([]byte(nil), R...)
+			xorBytes(block[8:], block[8:], bytesOf(big.NewInt(int64(j)), 8))
+			b.Encrypt(block, block)
+			S = append(S, block...)
+		}
+
+		m := u
+		if i % 2 == 1 {
+			m = v
+		}
+		c := num(A)
+		c.Add(c, new(big.Int).SetBytes(S[:d]))
+		c.Mod(c, new(big.Int).Exp(r, big.NewInt(int64(m)), nil))
+		A, B = B, str(c, m)
+	}
+	return append(append([]int(nil), A...), B...)
+}
+
+func TestFF1RC5(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher64(key, 20)
+
+	for _, radix := range []int{2, 10, 36, 62} {
+		f, _ := NewFF1(block, radix, 20, 64, nil)
+
+		for length := 20; length <= 64; length += 7 {
+			X := make([]int, length)
+			numerals := make([]byte, length)
+			for i := range X {
+				X[i] = random.Intn(radix)
+				numerals[i] = base62[X[i]]
+			}
+			tweak := make([]byte, random.Intn(20))
+			random.Read(tweak)
+
+			Y := ff1Reference(block, radix, X, tweak)
+			expected := make([]byte, length)
+			for i := range Y {
+				expected[i] = base62[Y[i]]
+			}
+
+			encrypted, err := f.EncryptWithTweak(string(numerals), tweak)
+			if err != nil || encrypted != string(expected) {
+				t.Errorf("FF1(radix %d, %q) == %q, %v, want %q", radix, numerals, encrypted, err, expected)
+			}
+			if decrypted, err := f.DecryptWithTweak(string(expected), tweak); err != nil || decrypted != string(numerals) {
+				t.Errorf("FF1(radix %d) decrypt of %q == %q, %v, want %q", radix, expected, decrypted, err, numerals)
+			}
+		}
+	}
+}
+
+func TestFF1(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher64(key, 20)
+
+	for _, radix := range []int{2, 10, 36, 62} {
+		f, err := NewFF1(block, radix, 20, 64, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for length := 20; length <= 64; length += 11 {
+			numerals := make([]byte, length)
+			for i := range numerals {
+				numerals[i] = base62[random.Intn(radix)]
+			}
+			x := string(numerals)
+			tweak := make([]byte, random.Intn(20))
+			random.Read(tweak)
+
+			y, err := f.EncryptWithTweak(x, tweak)
+			if err != nil {
+				t.Fatalf("FF1(radix %d, %d numerals) failed: %v", radix, length, err)
+			}
+
+			// same length, same alphabet, and back again
+			if len(y) != length || strings.Trim(y, base62[:radix]) != "" {
+				t.Errorf("FF1(radix %d) encrypted %q to %q", radix, x, y)
+			}
+			if back, err := f.DecryptWithTweak(y, tweak); err != nil || back != x {
+				t.Errorf("FF1(radix %d) round trip of %q == %q, %v", radix, x, back, err)
+			}
+
+			// another tweak gives another ciphertext
+			tweak = append(tweak, 0)
+			if other, _ := f.EncryptWithTweak(x, tweak); other == y {
+				t.Errorf("FF1(radix %d) ignored the tweak for %q", radix, x)
+			}
+		}
+	}
+}
+
+func TestFF1Errors(t *testing.T) {
+	block, _ := NewCipher64(seqBytes(16), 20)
+
+	if _, err := NewFF1(block, 63, 6, 10, nil); err != RadixError(63) {
+		t.Errorf("NewFF1(radix 63) error == %v, want RadixError", err)
+	}
+	if _, err := NewFF1(block, 10, 5, 10, nil); err != InputSizeError(5) {
+		t.Errorf("NewFF1(10^5 domain) error == %v, want InputSizeError", err)
+	}
+	if _, err := NewFF1WithAlphabet(block, "0120", 10, 20, nil); err != NumeralError("0120") {
+		t.Errorf("NewFF1WithAlphabet(repeated numeral) error == %v, want NumeralError", err)
+	}
+	small, _ := NewCipher32(seqBytes(16), 12)
+	if _, err := NewFF1(small, 10, 6, 10, nil); err != BlockSizeError(8) {
+		t.Errorf("NewFF1(64-bit block) error == %v, want BlockSizeError", err)
+	}
+
+	f, _ := NewFF1(block, 10, 6, 10, nil)
+	if _, err := f.Encrypt("12345"); err != InputSizeError(5) {
+		t.Errorf("Encrypt(5 digits) error == %v, want InputSizeError", err)
+	}
+	if _, err := f.Encrypt("12345678901"); err != InputSizeError(11) {
+		t.Errorf("Encrypt(11 digits) error == %v, want InputSizeError", err)
+	}
+	if _, err := f.Encrypt("1234a6"); err != NumeralError("1234a6") {
+		t.Errorf("Encrypt(\"1234a6\") error == %v, want NumeralError", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+// Low bits of the lexicographically first minimal weight irreducible
+// polynomial of each degree, keyed by block size in bytes. Doubling in
+// GF(2^n) reduces by these, as CMAC does for 64 and 128 bit blocks.
+var reductions = map[int]uint32{
+	2:   0x2b, 		// x^16 + x^5 + x^3 + x + 1
+	4:   0x8d, 		// x^32 + x^7 + x^3 + x^2 + 1
+	8:   0x1b, 		// x^64 + x^4 + x^3 + x + 1
+	16:  0x87, 		// x^128 + x^7 + x^2 + x + 1
+	32:  0x425, 	// x^256 + x^10 + x^5 + x^2 + 1
+	64:  0x125, 	// x^512 + x^8 + x^5 + x^2 + 1
+	128: 0x80043, 	// x^1024 + x^19 + x^6 + x + 1
+}
+
+// hasReduction reports whether blocks of bs bytes can be doubled.
+func hasReduction(bs int) bool {
+	_, ok := reductions[bs]
+	return ok
+}
+
+// double sets dst to src multiplied by x in GF(2^n), both big-endian n bit
+// blocks. dst may be src.
+func double(dst, src []byte) {
+	n := len(src)
+	msb := src[0] >> 7
+	for i := 0; i < n - 1; i++ {
+		dst[i] = src[i] << 1 | src[i + 1] >> 7
+	}
+	dst[n - 1] = src[n - 1] << 1
+
+	// constant time: the mask is all ones exactly when the top bit was set
+	mask := -uint32(msb)
+	r := reductions[n] & mask
+	for i := 0; i < 3 && i < n; i++ {
+		dst[n - 1 - i] ^= byte(r >> (8 * uint(i)))
+	}
+}
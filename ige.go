@@ -0,0 +1,92 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// IGE, infinite garble extension: C_i = E(P_i ^ C_i-1) ^ P_i-1. The IV is
+// two blocks, C_0 followed by P_0, as OpenSSL lays it out.
+
+type ige struct {
+	b 				cipher.Block 	// block cipher
+	blockSize 		int 			// block size in bytes
+	c 				[]byte 			// previous ciphertext block
+	p 				[]byte 			// previous plaintext block
+	tmp 			[]byte 			// scratch block
+}
+
+func newIGE(b cipher.Block, iv []byte) *ige {
+	bs := b.BlockSize()
+	x := ige{
+		b: b,
+		blockSize: bs,
+		c: make([]byte, bs),
+		p: make([]byte, bs),
+		tmp: make([]byte, bs),
+	}
+	copy(x.c, iv[:bs])
+	copy(x.p, iv[bs:])
+	return &x
+}
+
+type igeEncrypter ige
+
+// NewIGEEncrypter returns a BlockMode which encrypts in infinite garble
+// extension mode, using the given Block. The length of iv must be twice the
+// Block's block size.
+func NewIGEEncrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != 2 * b.BlockSize() {
+		panic("rc5.NewIGEEncrypter: IV length must equal two blocks")
+	}
+	return (*igeEncrypter)(newIGE(b, iv))
+}
+
+func (x *igeEncrypter) BlockSize() int { return x.blockSize }
+
+func (x *igeEncrypter) CryptBlocks(dst, src []byte) {
+	(*ige)(x).crypt(dst, src, x.b.Encrypt, x.c, x.p)
+}
+
+type igeDecrypter ige
+
+// NewIGEDecrypter returns a BlockMode which decrypts in infinite garble
+// extension mode, using the given Block. The length of iv must be twice the
+// Block's block size.
+func NewIGEDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != 2 * b.BlockSize() {
+		panic("rc5.NewIGEDecrypter: IV length must equal two blocks")
+	}
+	return (*igeDecrypter)(newIGE(b, iv))
+}
+
+func (x *igeDecrypter) BlockSize() int { return x.blockSize }
+
+func (x *igeDecrypter) CryptBlocks(dst, src []byte) {
+	(*ige)(x).crypt(dst, src, x.b.Decrypt, x.p, x.c)
+}
+
+// crypt is out_i = f(in_i ^ y_i-1) ^ x_i-1, where y is the previous output
+// and x the previous input, which is IGE in either direction.
+func (x *ige) crypt(dst, src []byte, f func(dst, src []byte), y, prev []byte) {
+	if len(src) % x.blockSize != 0 {
+		panic("rc5: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	bs := x.blockSize
+	for len(src) > 0 {
+		copy(x.tmp, src[:bs])
+		xorBytes(dst[:bs], src, y)
+		f(dst, dst[:bs])
+		xorBytes(dst[:bs], dst, prev)
+		copy(y, dst[:bs])
+		copy(prev, x.tmp)
+		dst, src = dst[bs:], src[bs:]
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+func TestIGEVector(t *testing.T) {
+	// AES-128 IGE vector from OpenSSL, checking the mode itself
+	block, _ := aes.NewCipher(seqBytes(16))
+	expected, _ := hex.DecodeString("1a8519a6557be652e9da8e43da4ef4453cf456b4ca488aa383c79c98b34797cb")
+
+	encrypted := make([]byte, 32)
+	NewIGEEncrypter(block, seqBytes(32)).CryptBlocks(encrypted, make([]byte, 32))
+	if !bytes.Equal(encrypted, expected) {
+		t.Errorf("IGE == % 02x, want % 02x", encrypted, expected)
+	}
+}
+
+// igeReference is C[i] = E(P[i] xor C[i-1]) xor P[i-1], with the IV
+// holding C[0] then P[0].
+func igeReference(b cipher.Block, iv, src []byte) []byte {
+	bs := b.BlockSize()
+	dst := make([]byte, len(src))
+	c, p := iv[:bs], iv[bs:]
+	x := make([]byte, bs)
+	for i := 0; i < len(src); i += bs {
+		xorBytes(x, src[i:i + bs], c)
+		b.Encrypt(dst[i:i + bs], x)
+		xorBytes(dst[i:i + bs], dst[i:i + bs], p)
+		c, p = dst[i:i + bs], src[i:i + bs]
+	}
+	return dst
+}
+
+func TestIGE(t *testing.T) {
+	testLegacyMode(t, "IGE", 2, igeReference, blockMode(NewIGEEncrypter), blockMode(NewIGEDecrypter), true)
+}
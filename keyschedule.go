@@ -0,0 +1,386 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"sync"
+)
+
+// Params selects an RC5 variant, RC5-W/R.
+type Params struct {
+	W 				uint 			// word size in bits
+	R 				uint 			// number of rounds
+}
+
+// KeySchedule holds an expanded key table. Passing the same KeySchedule to
+// ExpandKeys again reuses its table when the parameters have not changed.
+type KeySchedule struct {
+	W 				uint 			// word size in bits
+	R 				uint 			// number of rounds
+	s16 			[]uint16 		// expanded key table for W == 16
+	s32 			[]uint32 		// expanded key table for W == 32
+	s64 			[]uint64 		// expanded key table for W == 64
+	big 			*cipherBig 		// cipher for every other word size
+}
+
+// Block returns a cipher using the schedule. The cipher shares the
+// schedule's table, so it is only valid until the schedule is expanded again.
+// Block panics if the schedule has not been filled by ExpandKeys.
+func (k *KeySchedule) Block() cipher.Block {
+	T := 2 * (k.R + 1)
+	switch {
+		case k.W == 16 && uint(len(k.s16)) == T:
+		    return &cipher16{R: k.R, S: k.s16, T: T}
+		case k.W == 32 && uint(len(k.s32)) == T:
+		    return &cipher32{R: k.R, S: k.s32, T: T}
+		case k.W == 64 && uint(len(k.s64)) == T:
+		    return &cipher64{R: k.R, S: k.s64, T: T}
+		case k.W != 16 && k.W != 32 && k.W != 64 && k.big != nil:
+		    return k.big
+	}
+	panic("rc5: KeySchedule used before ExpandKeys")
+}
+
+// ExpandKeys expands keys[i] into out[i]. Keys of equal length are mixed four
+// at a time in lockstep, and the tables already held by out are reused. Every
+// schedule is identical to the one built by NewCipher for the same key.
+func ExpandKeys(params Params, keys [][]byte, out []KeySchedule) error {
+	if len(out) != len(keys) {
+		panic("rc5: mismatched key schedule count")
+	}
+	for _, key := range keys {
+		if n := len(key); n > 255 {
+			return KeySizeError(n)
+		}
+	}
+
+	switch params.W {
+		case 16:
+		    expandKeys16(params.R, keys, out)
+		case 32:
+		    expandKeys32(params.R, keys, out)
+		case 64:
+		    expandKeys64(params.R, keys, out)
+		default:
+		    for n := range keys {
+		    	out[n].W, out[n].R = params.W, params.R
+		    	out[n].big, _ = newCipherBig(keys[n], params.R, params.W)
+		    }
+	}
+	return nil
+}
+
+// ExpandKeysParallel is ExpandKeys split across workers goroutines.
+func ExpandKeysParallel(params Params, keys [][]byte, out []KeySchedule, workers int) error {
+	if len(out) != len(keys) {
+		panic("rc5: mismatched key schedule count")
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// keep every share a whole number of lockstep groups
+	share := (len(keys) + workers - 1) / workers
+	share = (share + lanes - 1) / lanes * lanes
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for w := 0; w < workers && w * share < len(keys); w++ {
+		lo, hi := w * share, (w + 1) * share
+		if hi > len(keys) {
+			hi = len(keys)
+		}
+		wg.Add(1)
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			errs[w] = ExpandKeys(params, keys[lo:hi], out[lo:hi])
+		}(w, lo, hi)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyWords is the number of WW byte words a key is padded to.
+func keyWords(key []byte, WW int) int {
+	if len(key) == 0 {
+		return 1
+	}
+	return (len(key) + WW - 1) / WW
+}
+
+// sameKeyWords reports whether every key pads to the same number of words.
+func sameKeyWords(keys [][]byte, WW int) bool {
+	for _, key := range keys[1:] {
+		if keyWords(key, WW) != keyWords(keys[0], WW) {
+			return false
+		}
+	}
+	return true
+}
+
+func expandKeys16(R uint, keys [][]byte, out []KeySchedule) {
+	T := 2 * (R + 1)
+	var S, L [lanes][]uint16
+
+	for n := 0; n < len(keys); n += lanes {
+		k := len(keys) - n
+		if k > lanes {
+			k = lanes
+		}
+
+		for l := 0; l < k; l++ {
+			o := &out[n + l]
+			if o.W != 16 || uint(len(o.s16)) != T {
+				o.s16 = make([]uint16, T)
+			}
+			o.W, o.R = 16, R
+			S[l] = o.s16
+			L[l] = loadKeyWords16(L[l], keys[n + l])
+			resetKeyTable16(S[l])
+		}
+
+		if k == lanes && sameKeyWords(keys[n:n + k], WW16) {
+			expandKeyTables16x4(&S, &L, T, uint(len(L[0])))
+			continue
+		}
+		for l := 0; l < k; l++ {
+			expandKeyTable16(S[l], T, L[l], uint(len(L[l])))
+		}
+	}
+}
+
+func expandKeys32(R uint, keys [][]byte, out []KeySchedule) {
+	T := 2 * (R + 1)
+	var S, L [lanes][]uint32
+
+	for n := 0; n < len(keys); n += lanes {
+		k := len(keys) - n
+		if k > lanes {
+			k = lanes
+		}
+
+		for l := 0; l < k; l++ {
+			o := &out[n + l]
+			if o.W != 32 || uint(len(o.s32)) != T {
+				o.s32 = make([]uint32, T)
+			}
+			o.W, o.R = 32, R
+			S[l] = o.s32
+			L[l] = loadKeyWords32(L[l], keys[n + l])
+			resetKeyTable32(S[l])
+		}
+
+		if k == lanes && sameKeyWords(keys[n:n + k], WW32) {
+			expandKeyTables32x4(&S, &L, T, uint(len(L[0])))
+			continue
+		}
+		for l := 0; l < k; l++ {
+			expandKeyTable32(S[l], T, L[l], uint(len(L[l])))
+		}
+	}
+}
+
+func expandKeys64(R uint, keys [][]byte, out []KeySchedule) {
+	T := 2 * (R + 1)
+	var S, L [lanes][]uint64
+
+	for n := 0; n < len(keys); n += lanes {
+		k := len(keys) - n
+		if k > lanes {
+			k = lanes
+		}
+
+		for l := 0; l < k; l++ {
+			o := &out[n + l]
+			if o.W != 64 || uint(len(o.s64)) != T {
+				o.s64 = make([]uint64, T)
+			}
+			o.W, o.R = 64, R
+			S[l] = o.s64
+			L[l] = loadKeyWords64(L[l], keys[n + l])
+			resetKeyTable64(S[l])
+		}
+
+		if k == lanes && sameKeyWords(keys[n:n + k], WW64) {
+			expandKeyTables64x4(&S, &L, T, uint(len(L[0])))
+			continue
+		}
+		for l := 0; l < k; l++ {
+			expandKeyTable64(S[l], T, L[l], uint(len(L[l])))
+		}
+	}
+}
+
+// loadKeyWords16 is bytesToWords16 writing into L when it is large enough.
+func loadKeyWords16(L []uint16, key []byte) []uint16 {
+	LL := keyWords(key, WW16)
+	if cap(L) < LL {
+		L = make([]uint16, LL)
+	}
+	L = L[:LL]
+
+	for i := range L {
+		L[i] = 0
+	}
+	for i := 0; i < len(key); i++ {
+		L[i / WW16] |= uint16(key[i]) << (8 * uint(i % WW16))
+	}
+	return L
+}
+
+// loadKeyWords32 is bytesToWords32 writing into L when it is large enough.
+func loadKeyWords32(L []uint32, key []byte) []uint32 {
+	LL := keyWords(key, WW32)
+	if cap(L) < LL {
+		L = make([]uint32, LL)
+	}
+	L = L[:LL]
+
+	for i := range L {
+		L[i] = 0
+	}
+	for i := 0; i < len(key); i++ {
+		L[i / WW32] |= uint32(key[i]) << (8 * uint(i % WW32))
+	}
+	return L
+}
+
+// loadKeyWords64 is bytesToWords64 writing into L when it is large enough.
+func loadKeyWords64(L []uint64, key []byte) []uint64 {
+	LL := keyWords(key, WW64)
+	if cap(L) < LL {
+		L = make([]uint64, LL)
+	}
+	L = L[:LL]
+
+	for i := range L {
+		L[i] = 0
+	}
+	for i := 0; i < len(key); i++ {
+		L[i / WW64] |= uint64(key[i]) << (8 * uint(i % WW64))
+	}
+	return L
+}
+
+// resetKeyTable16 is newKeyTable16 writing into S.
+func resetKeyTable16(S []uint16) {
+	S[0] = P16
+	for i := 1; i < len(S); i++ {
+		S[i] = S[i-1] + Q16
+	}
+}
+
+// resetKeyTable32 is newKeyTable32 writing into S.
+func resetKeyTable32(S []uint32) {
+	S[0] = P32
+	for i := 1; i < len(S); i++ {
+		S[i] = S[i-1] + Q32
+	}
+}
+
+// resetKeyTable64 is newKeyTable64 writing into S.
+func resetKeyTable64(S []uint64) {
+	S[0] = P64
+	for i := 1; i < len(S); i++ {
+		S[i] = S[i-1] + Q64
+	}
+}
+
+// expandKeyTables16x4 is expandKeyTable16 over four keys of LL words each.
+func expandKeyTables16x4(S, L *[lanes][]uint16, T uint, LL uint) {
+	S0, S1, S2, S3 := S[0], S[1], S[2], S[3]
+	L0, L1, L2, L3 := L[0], L[1], L[2], L[3]
+
+	k := 3 * T
+	if (LL > T) {
+		k = 3 * LL
+	}
+
+	var A0, A1, A2, A3 uint16
+	var B0, B1, B2, B3 uint16
+	i, j := uint(0), uint(0)
+
+	for ; k > 0; k-- {
+		A0 = rotl16(S0[i] + A0 + B0, 3)
+		A1 = rotl16(S1[i] + A1 + B1, 3)
+		A2 = rotl16(S2[i] + A2 + B2, 3)
+		A3 = rotl16(S3[i] + A3 + B3, 3)
+		S0[i], S1[i], S2[i], S3[i] = A0, A1, A2, A3
+		B0 = rotl16(L0[j] + A0 + B0, (A0 + B0)&15)
+		B1 = rotl16(L1[j] + A1 + B1, (A1 + B1)&15)
+		B2 = rotl16(L2[j] + A2 + B2, (A2 + B2)&15)
+		B3 = rotl16(L3[j] + A3 + B3, (A3 + B3)&15)
+		L0[j], L1[j], L2[j], L3[j] = B0, B1, B2, B3
+		i = (i + 1) % T
+		j = (j + 1) % LL
+	}
+}
+
+// expandKeyTables32x4 is expandKeyTable32 over four keys of LL words each.
+func expandKeyTables32x4(S, L *[lanes][]uint32, T uint, LL uint) {
+	S0, S1, S2, S3 := S[0], S[1], S[2], S[3]
+	L0, L1, L2, L3 := L[0], L[1], L[2], L[3]
+
+	k := 3 * T
+	if (LL > T) {
+		k = 3 * LL
+	}
+
+	var A0, A1, A2, A3 uint32
+	var B0, B1, B2, B3 uint32
+	i, j := uint(0), uint(0)
+
+	for ; k > 0; k-- {
+		A0 = rotl32(S0[i] + A0 + B0, 3)
+		A1 = rotl32(S1[i] + A1 + B1, 3)
+		A2 = rotl32(S2[i] + A2 + B2, 3)
+		A3 = rotl32(S3[i] + A3 + B3, 3)
+		S0[i], S1[i], S2[i], S3[i] = A0, A1, A2, A3
+		B0 = rotl32(L0[j] + A0 + B0, (A0 + B0)&31)
+		B1 = rotl32(L1[j] + A1 + B1, (A1 + B1)&31)
+		B2 = rotl32(L2[j] + A2 + B2, (A2 + B2)&31)
+		B3 = rotl32(L3[j] + A3 + B3, (A3 + B3)&31)
+		L0[j], L1[j], L2[j], L3[j] = B0, B1, B2, B3
+		i = (i + 1) % T
+		j = (j + 1) % LL
+	}
+}
+
+// expandKeyTables64x4 is expandKeyTable64 over four keys of LL words each.
+func expandKeyTables64x4(S, L *[lanes][]uint64, T uint, LL uint) {
+	S0, S1, S2, S3 := S[0], S[1], S[2], S[3]
+	L0, L1, L2, L3 := L[0], L[1], L[2], L[3]
+
+	k := 3 * T
+	if (LL > T) {
+		k = 3 * LL
+	}
+
+	var A0, A1, A2, A3 uint64
+	var B0, B1, B2, B3 uint64
+	i, j := uint(0), uint(0)
+
+	for ; k > 0; k-- {
+		A0 = rotl64(S0[i] + A0 + B0, 3)
+		A1 = rotl64(S1[i] + A1 + B1, 3)
+		A2 = rotl64(S2[i] + A2 + B2, 3)
+		A3 = rotl64(S3[i] + A3 + B3, 3)
+		S0[i], S1[i], S2[i], S3[i] = A0, A1, A2, A3
+		B0 = rotl64(L0[j] + A0 + B0, (A0 + B0)&63)
+		B1 = rotl64(L1[j] + A1 + B1, (A1 + B1)&63)
+		B2 = rotl64(L2[j] + A2 + B2, (A2 + B2)&63)
+		B3 = rotl64(L3[j] + A3 + B3, (A3 + B3)&63)
+		L0[j], L1[j], L2[j], L3[j] = B0, B1, B2, B3
+		i = (i + 1) % T
+		j = (j + 1) % LL
+	}
+}
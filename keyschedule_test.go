@@ -0,0 +1,118 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestExpandKeys(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	max := 50
+
+	for _, wordSize := range []uint{16, 32, 64, 24} {
+		params := Params{wordSize, 12}
+		out := make([]KeySchedule, 13)
+		outParallel := make([]KeySchedule, 13)
+		BB := int(wordSize / 4)
+
+		for i := 0; i < max; i++ {
+			keys := make([][]byte, len(out))
+			for n := range keys {
+				keys[n] = make([]byte, 16)
+				if i % 2 == 1 {
+					keys[n] = make([]byte, 8 + random.Intn(24))
+				}
+				random.Read(keys[n])
+			}
+
+			if err := ExpandKeys(params, keys, out); err != nil {
+				t.Fatal(err)
+			}
+			if err := ExpandKeysParallel(params, keys, outParallel, 3); err != nil {
+				t.Fatal(err)
+			}
+
+			value := make([]byte, BB)
+			random.Read(value)
+
+			for n, key := range keys {
+				expected := make([]byte, BB)
+				encrypted := make([]byte, BB)
+				encryptedParallel := make([]byte, BB)
+
+				cipher, _ := NewCipher(key, 12, wordSize)
+				cipher.Encrypt(expected, value)
+				out[n].Block().Encrypt(encrypted, value)
+				outParallel[n].Block().Encrypt(encryptedParallel, value)
+
+				if !bytes.Equal(encrypted, expected) {
+					t.Errorf("ExpandKeys(%d) failed: % 02x != % 02x\n", wordSize, encrypted, expected)
+				}
+				if !bytes.Equal(encryptedParallel, expected) {
+					t.Errorf("ExpandKeysParallel(%d) failed: % 02x != % 02x\n", wordSize, encryptedParallel, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestExpandKeysKeySize(t *testing.T) {
+	keys := [][]byte{make([]byte, 16), make([]byte, 256)}
+	out := make([]KeySchedule, 2)
+
+	if err := ExpandKeys(Params{32, 12}, keys, out); err != KeySizeError(256) {
+		t.Errorf("ExpandKeys error == %v, want %v", err, KeySizeError(256))
+	}
+}
+
+func TestKeyScheduleBlockUnexpanded(t *testing.T) {
+	for _, k := range []KeySchedule{{}, {W: 32, R: 12}, {W: 24, R: 12}} {
+		if !panics(func() { k.Block() }) {
+			t.Errorf("Block() of an unexpanded RC5-%d/%d schedule did not panic", k.W, k.R)
+		}
+	}
+
+	out := make([]KeySchedule, 1)
+	for _, w := range []uint{16, 24, 32, 64} {
+		ExpandKeys(Params{w, 12}, [][]byte{make([]byte, 16)}, out)
+		if panics(func() { out[0].Block() }) {
+			t.Errorf("Block() of an expanded RC5-%d/12 schedule panicked", w)
+		}
+	}
+}
+
+func BenchmarkExpandKeys32(b *testing.B) {
+	random := rand.New(rand.NewSource(99))
+	keys := make([][]byte, 64)
+	for n := range keys {
+		keys[n] = make([]byte, 16)
+		random.Read(keys[n])
+	}
+	out := make([]KeySchedule, len(keys))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ExpandKeys(Params{32, 12}, keys, out)
+	}
+}
+
+func BenchmarkNewCipher32(b *testing.B) {
+	random := rand.New(rand.NewSource(99))
+	keys := make([][]byte, 64)
+	for n := range keys {
+		keys[n] = make([]byte, 16)
+		random.Read(keys[n])
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			newCipher32(key, 12)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+)
+
+const keyWrapBlockSize = 16 		// RFC 3394 and 5649 wrap with 128-bit blocks
+
+var (
+	keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	keyWrapPadIV = []byte{0xa6, 0x59, 0x59, 0xa6}
+	cmsWrapIV = []byte{0x4a, 0xdd, 0xa2, 0x2c, 0x79, 0xe8, 0x21, 0x05}
+)
+
+// Wrap encrypts key under the key encryption key b, which must have 128-bit
+// blocks such as RC5-64, with the AES key wrap algorithm of RFC 3394. key
+// must be a multiple of 8 bytes and at least 16 bytes long. The result is 8
+// bytes longer than key.
+func Wrap(b cipher.Block, key []byte) ([]byte, error) {
+	if bs := b.BlockSize(); bs != keyWrapBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if len(key) % 8 != 0 || len(key) < 16 {
+		return nil, InputSizeError(len(key))
+	}
+	return wrap(b, keyWrapIV, key), nil
+}
+
+// Unwrap decrypts a key wrapped by Wrap, returning an IntegrityError if it
+// was not wrapped under b.
+func Unwrap(b cipher.Block, wrapped []byte) ([]byte, error) {
+	if bs := b.BlockSize(); bs != keyWrapBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if len(wrapped) % 8 != 0 || len(wrapped) < 24 {
+		return nil, InputSizeError(len(wrapped))
+	}
+
+	a, key := unwrap(b, wrapped)
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, IntegrityError{}
+	}
+	return key, nil
+}
+
+// WrapPad encrypts key, which may have any length but zero, under the key
+// encryption key b, which must have 128-bit blocks, with the padded key wrap
+// algorithm of RFC 5649. The result is key rounded up to 8 bytes, plus 8.
+func WrapPad(b cipher.Block, key []byte) ([]byte, error) {
+	if bs := b.BlockSize(); bs != keyWrapBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if len(key) == 0 || uint64(len(key)) > 0xffffffff {
+		return nil, InputSizeError(len(key))
+	}
+
+	aiv := make([]byte, 8)
+	copy(aiv, keyWrapPadIV)
+	binary.BigEndian.PutUint32(aiv[4:], uint32(len(key)))
+
+	padded := make([]byte, (len(key) + 7) / 8 * 8)
+	copy(padded, key)
+
+	// a single padded block is encrypted directly
+	if len(padded) == 8 {
+		out := make([]byte, keyWrapBlockSize)
+		copy(out, aiv)
+		copy(out[8:], padded)
+		b.Encrypt(out, out)
+		return out, nil
+	}
+	return wrap(b, aiv, padded), nil
+}
+
+// UnwrapPad decrypts a key wrapped by WrapPad, returning an IntegrityError
+// if it was not wrapped under b.
+func UnwrapPad(b cipher.Block, wrapped []byte) ([]byte, error) {
+	if bs := b.BlockSize(); bs != keyWrapBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if len(wrapped) % 8 != 0 || len(wrapped) < 16 {
+		return nil, InputSizeError(len(wrapped))
+	}
+
+	var a, padded []byte
+	if len(wrapped) == keyWrapBlockSize {
+		out := make([]byte, keyWrapBlockSize)
+		b.Decrypt(out, wrapped)
+		a, padded = out[:8], out[8:]
+	} else {
+		a, padded = unwrap(b, wrapped)
+	}
+
+	// the message length must leave 0 to 7 zero bytes of padding
+	mli := int(binary.BigEndian.Uint32(a[4:]))
+	good := subtle.ConstantTimeCompare(a[:4], keyWrapPadIV)
+	good &= subtle.ConstantTimeLessOrEq(len(padded) - 7, mli)
+	good &= subtle.ConstantTimeLessOrEq(mli, len(padded))
+	var zero byte
+	for i := len(padded) - 7; i < len(padded); i++ {
+		inPad := subtle.ConstantTimeLessOrEq(mli, i)
+		zero |= padded[i] & byte(-inPad)
+	}
+	good &= subtle.ConstantTimeByteEq(zero, 0)
+	if good != 1 {
+		return nil, IntegrityError{}
+	}
+	return padded[:mli], nil
+}
+
+// wrap is the index based wrapping process W of RFC 3394 section 2.2.1 with
+// initial value iv over the 64-bit blocks of p.
+func wrap(b cipher.Block, iv, p []byte) []byte {
+	n := len(p) / 8
+	out := make([]byte, 8 + len(p))
+	a := out[:8]
+	copy(a, iv)
+	copy(out[8:], p)
+
+	buf := make([]byte, keyWrapBlockSize)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			r := out[8 * i:8 * i + 8]
+			copy(buf, a)
+			copy(buf[8:], r)
+			b.Encrypt(buf, buf)
+
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf) ^ uint64(n * j + i))
+			copy(r, buf[8:])
+		}
+	}
+	return out
+}
+
+// unwrap inverts wrap, returning the recovered initial value and the key.
+func unwrap(b cipher.Block, c []byte) ([]byte, []byte) {
+	n := len(c) / 8 - 1
+	out := make([]byte, len(c))
+	copy(out, c)
+	a := out[:8]
+
+	buf := make([]byte, keyWrapBlockSize)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			r := out[8 * i:8 * i + 8]
+			binary.BigEndian.PutUint64(buf, binary.BigEndian.Uint64(a) ^ uint64(n * j + i))
+			copy(buf[8:], r)
+			b.Decrypt(buf, buf)
+
+			copy(a, buf)
+			copy(r, buf[8:])
+		}
+	}
+	return a, out[8:]
+}
+
+// CMSWrap encrypts key, of 1 to 255 bytes, under the key encryption key b,
+// which must have 64-bit blocks such as RC5-32, with the RC2 key wrap of RFC
+// 3217 section 4: a length byte, random padding to whole blocks and a SHA-1
+// check value, CBC encrypted under a random IV, reversed and CBC encrypted
+// again under a fixed IV. random supplies the padding and IV, and is
+// typically crypto/rand.Reader.
+func CMSWrap(b cipher.Block, key []byte, random io.Reader) ([]byte, error) {
+	if bs := b.BlockSize(); bs != 8 {
+		return nil, BlockSizeError(bs)
+	}
+	if len(key) == 0 || len(key) > 255 {
+		return nil, InputSizeError(len(key))
+	}
+
+	// IV || LENGTH || CEK || PAD || ICV
+	lcek := 1 + len(key)
+	padded := (lcek + 7) / 8 * 8
+	out := make([]byte, 8 + padded + 8)
+	out[8] = byte(len(key))
+	copy(out[9:], key)
+	if _, err := io.ReadFull(random, out[8 + lcek:8 + padded]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(random, out[:8]); err != nil {
+		return nil, err
+	}
+	icv := sha1.Sum(out[8:8 + padded])
+	copy(out[8 + padded:], icv[:8])
+
+	NewCBCEncrypter(b, out[:8]).CryptBlocks(out[8:], out[8:])
+	reverse(out)
+	NewCBCEncrypter(b, cmsWrapIV).CryptBlocks(out, out)
+	return out, nil
+}
+
+// CMSUnwrap decrypts a key wrapped by CMSWrap, returning an IntegrityError
+// if it was not wrapped under b.
+func CMSUnwrap(b cipher.Block, wrapped []byte) ([]byte, error) {
+	if bs := b.BlockSize(); bs != 8 {
+		return nil, BlockSizeError(bs)
+	}
+	if len(wrapped) % 8 != 0 || len(wrapped) < 24 {
+		return nil, InputSizeError(len(wrapped))
+	}
+
+	out := make([]byte, len(wrapped))
+	NewCBCDecrypter(b, cmsWrapIV).CryptBlocks(out, wrapped)
+	reverse(out)
+	NewCBCDecrypter(b, out[:8]).CryptBlocks(out[8:], out[8:])
+
+	lcekpad := out[8:len(out) - 8]
+	icv := sha1.Sum(lcekpad)
+	length := int(lcekpad[0])
+
+	// at most 7 bytes of padding may follow the key
+	good := subtle.ConstantTimeCompare(icv[:8], out[len(out) - 8:])
+	good &= subtle.ConstantTimeLessOrEq(len(lcekpad) - 8, length)
+	good &= subtle.ConstantTimeLessOrEq(length, len(lcekpad) - 1)
+	if good != 1 {
+		return nil, IntegrityError{}
+	}
+	return lcekpad[1:1 + length], nil
+}
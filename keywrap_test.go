@@ -0,0 +1,135 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestWrapVectors(t *testing.T) {
+	// AES key wrap from RFC 3394 section 4 and RFC 5649 section 6, checking
+	// the algorithms
+	for _, v := range []struct {
+		pad 			bool
+		kek 			string
+		key 			string
+		wrapped 		string
+	}{
+		{false, "000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff",
+			"1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5"},
+		{false, "000102030405060708090a0b0c0d0e0f1011121314151617", "00112233445566778899aabbccddeeff0001020304050607",
+			"031d33264e15d33268f24ec260743edce1c6c7ddee725a936ba814915c6762d2"},
+		{true, "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "c37b7e6492584340bed12207808941155068f738",
+			"138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"},
+		{true, "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "466f7250617369",
+			"afbeb0f07dfbf5419200f2ccb50bb24f"},
+	} {
+		kek, _ := hex.DecodeString(v.kek)
+		key, _ := hex.DecodeString(v.key)
+		expected, _ := hex.DecodeString(v.wrapped)
+		block, _ := aes.NewCipher(kek)
+
+		wrapFunc, unwrapFunc := Wrap, Unwrap
+		if v.pad {
+			wrapFunc, unwrapFunc = WrapPad, UnwrapPad
+		}
+
+		wrapped, err := wrapFunc(block, key)
+		if err != nil || !bytes.Equal(wrapped, expected) {
+			t.Errorf("wrap(%s) == % 02x, %v, want % 02x", v.key, wrapped, err, expected)
+		}
+		unwrapped, err := unwrapFunc(block, expected)
+		if err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("unwrap(%s) == % 02x, %v, want % 02x", v.wrapped, unwrapped, err, key)
+		}
+	}
+}
+
+func TestWrapRC5(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	kek := make([]byte, 16)
+	random.Read(kek)
+	block, _ := NewCipher64(kek, 24)
+	other, _ := NewCipher64(kek[1:], 24)
+
+	for size := 1; size <= 40; size++ {
+		key := make([]byte, size)
+		random.Read(key)
+
+		wrapped, err := WrapPad(block, key)
+		if err != nil || len(wrapped) != (size + 7) / 8 * 8 + 8 {
+			t.Fatalf("WrapPad(%d bytes) == %d bytes, %v", size, len(wrapped), err)
+		}
+		if unwrapped, err := UnwrapPad(block, wrapped); err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("UnwrapPad(%d bytes) round trip failed: %v", size, err)
+		}
+		if _, err := UnwrapPad(other, wrapped); err != (IntegrityError{}) {
+			t.Errorf("UnwrapPad(%d bytes) under the wrong key error == %v, want IntegrityError", size, err)
+		}
+
+		if size % 8 != 0 || size < 16 {
+			if _, err := Wrap(block, key); err != InputSizeError(size) {
+				t.Errorf("Wrap(%d bytes) error == %v, want InputSizeError", size, err)
+			}
+			continue
+		}
+
+		wrapped, _ = Wrap(block, key)
+		if unwrapped, err := Unwrap(block, wrapped); err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("Unwrap(%d bytes) round trip failed: %v", size, err)
+		}
+		wrapped[len(wrapped) - 1] ^= 1
+		if _, err := Unwrap(block, wrapped); err != (IntegrityError{}) {
+			t.Errorf("Unwrap(%d bytes) of a modified key error == %v, want IntegrityError", size, err)
+		}
+	}
+
+	small, _ := NewCipher32(kek, 12)
+	if _, err := Wrap(small, kek); err != BlockSizeError(8) {
+		t.Errorf("Wrap with a 64-bit block error == %v, want BlockSizeError", err)
+	}
+}
+
+func TestCMSWrap(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	kek := make([]byte, 16)
+	random.Read(kek)
+	block, _ := NewCipher32(kek, 12)
+
+	for _, size := range []int{1, 5, 7, 8, 16, 255} {
+		key := make([]byte, size)
+		random.Read(key)
+
+		wrapped, err := CMSWrap(block, key, random)
+		if err != nil || len(wrapped) != (size + 8) / 8 * 8 + 16 {
+			t.Fatalf("CMSWrap(%d bytes) == %d bytes, %v", size, len(wrapped), err)
+		}
+		if unwrapped, err := CMSUnwrap(block, wrapped); err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("CMSUnwrap(%d bytes) round trip failed: %v", size, err)
+		}
+
+		// the random IV makes every wrapping different
+		again, _ := CMSWrap(block, key, random)
+		if bytes.Equal(again, wrapped) {
+			t.Errorf("CMSWrap(%d bytes) repeated its output", size)
+		}
+
+		for i := 0; i < len(wrapped); i += 8 {
+			wrapped[i] ^= 0x10
+			if _, err := CMSUnwrap(block, wrapped); err != (IntegrityError{}) {
+				t.Errorf("CMSUnwrap(%d bytes) with byte %d modified error == %v, want IntegrityError", size, i, err)
+			}
+			wrapped[i] ^= 0x10
+		}
+	}
+
+	if _, err := CMSWrap(block, make([]byte, 256), random); err != InputSizeError(256) {
+		t.Errorf("CMSWrap(256 bytes) error == %v, want InputSizeError", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// number of independent lanes processed together
+const lanes = 4
+
+// MultiBuffer encrypts independent RC5-32 blocks, each under its own key.
+// Lanes are processed four at a time: their key schedules and rounds are
+// interleaved so that the work of one lane hides the latency of the others.
+// The result for every lane is identical to NewCipher32 followed by Encrypt.
+//
+// A MultiBuffer reuses its key tables between calls and is not safe for
+// concurrent use.
+type MultiBuffer struct {
+	R 				uint 				// number of rounds
+	T 				uint 				// number of words in expanded key table
+	S 				[lanes][]uint32 	// expanded key tables, one per lane
+	L 				[lanes][]uint32 	// secret key words, one per lane
+}
+
+func NewMultiBuffer(rounds uint) *MultiBuffer {
+	m := MultiBuffer{R: rounds, T: 2 * (rounds + 1)}
+	for l := 0; l < lanes; l++ {
+		m.S[l] = make([]uint32, m.T)
+	}
+	return &m
+}
+
+// EncryptKeys encrypts src[i] under keys[i] into dst[i].
+func (m *MultiBuffer) EncryptKeys(dst, src, keys [][]byte) error {
+	return m.cryptKeys(dst, src, keys, true)
+}
+
+// DecryptKeys decrypts src[i] under keys[i] into dst[i].
+func (m *MultiBuffer) DecryptKeys(dst, src, keys [][]byte) error {
+	return m.cryptKeys(dst, src, keys, false)
+}
+
+// Encrypt encrypts src[i] with blocks[i] into dst[i]. Lanes whose block was
+// not returned by NewCipher32 are encrypted one at a time.
+func (m *MultiBuffer) Encrypt(dst, src [][]byte, blocks []cipher.Block) {
+	m.crypt(dst, src, blocks, true)
+}
+
+// Decrypt decrypts src[i] with blocks[i] into dst[i].
+func (m *MultiBuffer) Decrypt(dst, src [][]byte, blocks []cipher.Block) {
+	m.crypt(dst, src, blocks, false)
+}
+
+func (m *MultiBuffer) cryptKeys(dst, src, keys [][]byte, encrypt bool) error {
+	if len(dst) != len(src) || len(keys) != len(src) {
+		panic("rc5: mismatched lane count")
+	}
+	for _, key := range keys {
+		if n := len(key); n > 255 {
+			return KeySizeError(n)
+		}
+	}
+
+	for n := 0; n < len(src); n += lanes {
+		k := len(src) - n
+		if k > lanes {
+			k = lanes
+		}
+		m.expand(keys[n:n + k])
+		crypt32x4(dst[n:n + k], src[n:n + k], &m.S, m.R, k, encrypt)
+	}
+	return nil
+}
+
+func (m *MultiBuffer) crypt(dst, src [][]byte, blocks []cipher.Block, encrypt bool) {
+	if len(dst) != len(src) || len(blocks) != len(src) {
+		panic("rc5: mismatched lane count")
+	}
+
+	var S [lanes][]uint32
+	for n := 0; n < len(src); n += lanes {
+		k := len(src) - n
+		if k > lanes {
+			k = lanes
+		}
+
+		grouped := true
+		for l := 0; l < k; l++ {
+			c, ok := blocks[n + l].(*cipher32)
+			if !ok || c.R != m.R {
+				grouped = false
+				break
+			}
+			S[l] = c.S
+		}
+
+		if grouped {
+			crypt32x4(dst[n:n + k], src[n:n + k], &S, m.R, k, encrypt)
+			continue
+		}
+
+		for l := 0; l < k; l++ {
+			if encrypt {
+				blocks[n + l].Encrypt(dst[n + l], src[n + l])
+			} else {
+				blocks[n + l].Decrypt(dst[n + l], src[n + l])
+			}
+		}
+	}
+}
+
+// expand fills m.S[:len(keys)] with the key tables for keys. When every key
+// has the same length the tables are mixed in lockstep.
+func (m *MultiBuffer) expand(keys [][]byte) {
+	for l := 0; l < len(keys); l++ {
+		m.L[l] = loadKeyWords32(m.L[l], keys[l])
+		resetKeyTable32(m.S[l])
+	}
+
+	if len(keys) == lanes && sameKeyWords(keys, WW32) {
+		expandKeyTables32x4(&m.S, &m.L, m.T, uint(len(m.L[0])))
+		return
+	}
+	for l := 0; l < len(keys); l++ {
+		expandKeyTable32(m.S[l], m.T, m.L[l], uint(len(m.L[l])))
+	}
+}
+
+// crypt32x4 runs the first k lanes of dst/src through the key tables in S.
+// A full group of four is interleaved; a partial group runs lane by lane.
+func crypt32x4(dst, src [][]byte, S *[lanes][]uint32, R uint, k int, encrypt bool) {
+	if k < lanes {
+		for l := 0; l < k; l++ {
+			c := cipher32{R: R, S: S[l], T: 2 * (R + 1)}
+			if encrypt {
+				c.Encrypt(dst[l], src[l])
+			} else {
+				c.Decrypt(dst[l], src[l])
+			}
+		}
+		return
+	}
+
+	S0, S1, S2, S3 := S[0], S[1], S[2], S[3]
+	A0, B0 := get32(src[0])
+	A1, B1 := get32(src[1])
+	A2, B2 := get32(src[2])
+	A3, B3 := get32(src[3])
+
+	if encrypt {
+		A0, B0 = A0 + S0[0], B0 + S0[1]
+		A1, B1 = A1 + S1[0], B1 + S1[1]
+		A2, B2 = A2 + S2[0], B2 + S2[1]
+		A3, B3 = A3 + S3[0], B3 + S3[1]
+
+		for i := uint(1); i <= R; i++ {
+			A0 = rotl32(A0^B0, B0&31) + S0[2 * i]
+			A1 = rotl32(A1^B1, B1&31) + S1[2 * i]
+			A2 = rotl32(A2^B2, B2&31) + S2[2 * i]
+			A3 = rotl32(A3^B3, B3&31) + S3[2 * i]
+			B0 = rotl32(B0^A0, A0&31) + S0[2 * i + 1]
+			B1 = rotl32(B1^A1, A1&31) + S1[2 * i + 1]
+			B2 = rotl32(B2^A2, A2&31) + S2[2 * i + 1]
+			B3 = rotl32(B3^A3, A3&31) + S3[2 * i + 1]
+		}
+	} else {
+		for i := R; i >= 1; i-- {
+			B0 = rotr32(B0 - S0[2 * i + 1], A0&31) ^ A0
+			B1 = rotr32(B1 - S1[2 * i + 1], A1&31) ^ A1
+			B2 = rotr32(B2 - S2[2 * i + 1], A2&31) ^ A2
+			B3 = rotr32(B3 - S3[2 * i + 1], A3&31) ^ A3
+			A0 = rotr32(A0 - S0[2 * i], B0&31) ^ B0
+			A1 = rotr32(A1 - S1[2 * i], B1&31) ^ B1
+			A2 = rotr32(A2 - S2[2 * i], B2&31) ^ B2
+			A3 = rotr32(A3 - S3[2 * i], B3&31) ^ B3
+		}
+
+		A0, B0 = A0 - S0[0], B0 - S0[1]
+		A1, B1 = A1 - S1[0], B1 - S1[1]
+		A2, B2 = A2 - S2[0], B2 - S2[1]
+		A3, B3 = A3 - S3[0], B3 - S3[1]
+	}
+
+	put32(dst[0], A0, B0)
+	put32(dst[1], A1, B1)
+	put32(dst[2], A2, B2)
+	put32(dst[3], A3, B3)
+}
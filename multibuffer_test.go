@@ -0,0 +1,88 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"math/rand"
+	"testing"
+)
+
+func TestMultiBuffer(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	max := 500
+
+	m := NewMultiBuffer(12)
+
+	for i := 0; i < max; i++ {
+		n := 1 + random.Intn(11)
+		keys := make([][]byte, n)
+		src := make([][]byte, n)
+		dst := make([][]byte, n)
+		dstKeys := make([][]byte, n)
+		blocks := make([]cipher.Block, n)
+
+		for l := 0; l < n; l++ {
+			keys[l] = make([]byte, 16)
+			if i % 2 == 1 {
+				keys[l] = make([]byte, 4 + 4 * random.Intn(8))
+			}
+			random.Read(keys[l])
+			src[l] = make([]byte, 8)
+			random.Read(src[l])
+			dst[l] = make([]byte, 8)
+			dstKeys[l] = make([]byte, 8)
+			blocks[l], _ = NewCipher32(keys[l], 12)
+		}
+
+		m.Encrypt(dst, src, blocks)
+		if err := m.EncryptKeys(dstKeys, src, keys); err != nil {
+			t.Fatal(err)
+		}
+
+		for l := 0; l < n; l++ {
+			expected := make([]byte, 8)
+			blocks[l].Encrypt(expected, src[l])
+
+			if !bytes.Equal(dst[l], expected) {
+				t.Errorf("Encrypt lane %d failed: % 02x != % 02x\n", l, dst[l], expected)
+			}
+			if !bytes.Equal(dstKeys[l], expected) {
+				t.Errorf("EncryptKeys lane %d failed: % 02x != % 02x\n", l, dstKeys[l], expected)
+			}
+		}
+
+		m.Decrypt(dst, dst, blocks)
+		m.DecryptKeys(dstKeys, dstKeys, keys)
+
+		for l := 0; l < n; l++ {
+			if !bytes.Equal(dst[l], src[l]) {
+				t.Errorf("Decrypt lane %d failed: % 02x != % 02x\n", l, dst[l], src[l])
+			}
+			if !bytes.Equal(dstKeys[l], src[l]) {
+				t.Errorf("DecryptKeys lane %d failed: % 02x != % 02x\n", l, dstKeys[l], src[l])
+			}
+		}
+	}
+}
+
+func BenchmarkMultiBufferKeys(b *testing.B) {
+	random := rand.New(rand.NewSource(99))
+	m := NewMultiBuffer(12)
+
+	keys := make([][]byte, 64)
+	src := make([][]byte, 64)
+	for l := range keys {
+		keys[l] = make([]byte, 16)
+		random.Read(keys[l])
+		src[l] = make([]byte, 8)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.EncryptKeys(src, src, keys)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"math/bits"
+)
+
+const (
+	ocbBlockSize 		= 16 		// OCB3 is defined for 128-bit blocks only
+	ocbNonceSize 		= 12 		// default nonce size in bytes
+	ocbTagSize 			= 16 		// default tag size in bytes
+)
+
+type ocb struct {
+	b 				cipher.Block 	// block cipher
+	nonceSize 		int 			// nonce size in bytes
+	tagSize 		int 			// tag size in bytes
+	lStar 			[]byte 			// L_* = E(0)
+	lDollar 		[]byte 			// L_$ = double(L_*)
+	l 				[][]byte 		// L_i = double^(i+1)(L_$)
+}
+
+// NewOCB returns OCB3, the single-pass authenticated encryption mode of
+// RFC 7253, over a 128-bit block such as RC5-64, with 96-bit nonces and
+// 128-bit tags.
+func NewOCB(b cipher.Block) (cipher.AEAD, error) {
+	return NewOCBWithSizes(b, ocbNonceSize, ocbTagSize)
+}
+
+// NewOCBWithSizes is NewOCB with a nonce of 1 to 15 bytes and a tag of 1 to
+// 16 bytes, both folded into the nonce formatting as RFC 7253 specifies.
+func NewOCBWithSizes(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if bs := b.BlockSize(); bs != ocbBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if nonceSize < 1 || nonceSize > 15 {
+		return nil, NonceSizeError(nonceSize)
+	}
+	if tagSize < 1 || tagSize > ocbTagSize {
+		return nil, TagSizeError(tagSize)
+	}
+
+	o := ocb{b: b, nonceSize: nonceSize, tagSize: tagSize}
+	o.lStar = make([]byte, ocbBlockSize)
+	b.Encrypt(o.lStar, o.lStar)
+	o.lDollar = make([]byte, ocbBlockSize)
+	double(o.lDollar, o.lStar)
+
+	// one L_i per possible trailing zero count of a 64-bit block index
+	o.l = make([][]byte, 64)
+	prev := o.lDollar
+	for i := range o.l {
+		o.l[i] = make([]byte, ocbBlockSize)
+		double(o.l[i], prev)
+		prev = o.l[i]
+	}
+	return &o, nil
+}
+
+func (o *ocb) NonceSize() int { return o.nonceSize }
+
+func (o *ocb) Overhead() int { return o.tagSize }
+
+func (o *ocb) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != o.nonceSize {
+		panic("rc5: incorrect nonce length given to OCB")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext) + o.tagSize)
+	offset := o.initialOffset(nonce)
+	checksum := make([]byte, ocbBlockSize)
+	tmp := make([]byte, ocbBlockSize)
+
+	i := uint64(1)
+	for ; len(plaintext) >= ocbBlockSize; i++ {
+		xorBytes(offset, offset, o.l[bits.TrailingZeros64(i)])
+		xorBytes(checksum, checksum, plaintext)
+		xorBytes(tmp, plaintext, offset)
+		o.b.Encrypt(tmp, tmp)
+		xorBytes(out, tmp, offset)
+		plaintext, out = plaintext[ocbBlockSize:], out[ocbBlockSize:]
+	}
+
+	if len(plaintext) > 0 {
+		xorBytes(offset, offset, o.lStar)
+		o.b.Encrypt(tmp, offset)
+		xorBytes(out, plaintext, tmp)
+		xorBytes(checksum, checksum, plaintext)
+		checksum[len(plaintext)] ^= 0x80
+		out = out[len(plaintext):]
+	}
+
+	copy(out, o.tag(checksum, offset, additionalData))
+	return ret
+}
+
+func (o *ocb) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != o.nonceSize {
+		panic("rc5: incorrect nonce length given to OCB")
+	}
+	if len(ciphertext) < o.tagSize {
+		return nil, AuthenticationError{}
+	}
+
+	tag := ciphertext[len(ciphertext) - o.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext) - o.tagSize]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	plaintext := out
+	offset := o.initialOffset(nonce)
+	checksum := make([]byte, ocbBlockSize)
+	tmp := make([]byte, ocbBlockSize)
+
+	i := uint64(1)
+	for ; len(ciphertext) >= ocbBlockSize; i++ {
+		xorBytes(offset, offset, o.l[bits.TrailingZeros64(i)])
+		xorBytes(tmp, ciphertext, offset)
+		o.b.Decrypt(tmp, tmp)
+		xorBytes(out, tmp, offset)
+		xorBytes(checksum, checksum, out)
+		ciphertext, out = ciphertext[ocbBlockSize:], out[ocbBlockSize:]
+	}
+
+	if len(ciphertext) > 0 {
+		xorBytes(offset, offset, o.lStar)
+		o.b.Encrypt(tmp, offset)
+		xorBytes(out, ciphertext, tmp)
+		xorBytes(checksum, checksum, out[:len(ciphertext)])
+		checksum[len(ciphertext)] ^= 0x80
+	}
+
+	if subtle.ConstantTimeCompare(o.tag(checksum, offset, additionalData), tag) != 1 {
+		// do not hand back unauthenticated plaintext
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, AuthenticationError{}
+	}
+	return ret, nil
+}
+
+// initialOffset is Offset_0, derived from the formatted nonce.
+func (o *ocb) initialOffset(nonce []byte) []byte {
+	// Nonce = num2str(TAGLEN mod 128, 7) || zeros || 1 || N
+	n := make([]byte, ocbBlockSize)
+	n[0] = byte((o.tagSize * 8) % 128) << 1
+	n[ocbBlockSize - len(nonce) - 1] |= 1
+	copy(n[ocbBlockSize - len(nonce):], nonce)
+
+	bottom := uint(n[ocbBlockSize - 1] & 0x3f)
+	n[ocbBlockSize - 1] &= 0xc0
+
+	// Stretch = Ktop || (Ktop[1..64] xor Ktop[9..72])
+	stretch := make([]byte, ocbBlockSize + 8)
+	o.b.Encrypt(stretch, n)
+	for i := 0; i < 8; i++ {
+		stretch[ocbBlockSize + i] = stretch[i] ^ stretch[i + 1]
+	}
+
+	// Offset_0 = Stretch[1+bottom..128+bottom]
+	offset := make([]byte, ocbBlockSize)
+	shift, skip := bottom % 8, bottom / 8
+	for i := range offset {
+		offset[i] = stretch[skip + uint(i)] << shift
+		if shift > 0 {
+			offset[i] |= stretch[skip + uint(i) + 1] >> (8 - shift)
+		}
+	}
+	return offset
+}
+
+// tag is E(Checksum ^ Offset ^ L_$) ^ HASH(K, A), truncated to the tag size.
+func (o *ocb) tag(checksum, offset, additionalData []byte) []byte {
+	t := make([]byte, ocbBlockSize)
+	xorBytes(t, checksum, offset)
+	xorBytes(t, t, o.lDollar)
+	o.b.Encrypt(t, t)
+	xorBytes(t, t, o.hash(additionalData))
+	return t[:o.tagSize]
+}
+
+// hash is HASH(K, A) of RFC 7253 section 4.1.
+func (o *ocb) hash(a []byte) []byte {
+	sum := make([]byte, ocbBlockSize)
+	offset := make([]byte, ocbBlockSize)
+	tmp := make([]byte, ocbBlockSize)
+
+	i := uint64(1)
+	for ; len(a) >= ocbBlockSize; i++ {
+		xorBytes(offset, offset, o.l[bits.TrailingZeros64(i)])
+		xorBytes(tmp, a, offset)
+		o.b.Encrypt(tmp, tmp)
+		xorBytes(sum, sum, tmp)
+		a = a[ocbBlockSize:]
+	}
+
+	if len(a) > 0 {
+		xorBytes(offset, offset, o.lStar)
+		copy(tmp, offset)
+		xorBytes(tmp, tmp, a)
+		tmp[len(a)] ^= 0x80
+		o.b.Encrypt(tmp, tmp)
+		xorBytes(sum, sum, tmp)
+	}
+	return sum
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// AES-128 vectors from RFC 7253 appendix A, checking the mode itself.
+var ocbVectors = []struct {
+	nonce 			string
+	ad 				string
+	plain 			string
+	cipher 			string
+}{
+	{"bbaa99887766554433221100", "", "",
+		"785407bfffc8ad9edcc5520ac9111ee6"},
+	{"bbaa99887766554433221101", "0001020304050607", "0001020304050607",
+		"6820b3657b6f615a5725bda0d3b4eb3a257c9af1f8f03009"},
+}
+
+func TestOCBVectors(t *testing.T) {
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	block, _ := aes.NewCipher(key)
+	aead, _ := NewOCB(block)
+
+	for n, v := range ocbVectors {
+		nonce, _ := hex.DecodeString(v.nonce)
+		ad, _ := hex.DecodeString(v.ad)
+		plain, _ := hex.DecodeString(v.plain)
+		expected, _ := hex.DecodeString(v.cipher)
+
+		if sealed := aead.Seal(nil, nonce, plain, ad); !bytes.Equal(sealed, expected) {
+			t.Errorf("vector %d: Seal == % 02x, want % 02x", n + 1, sealed, expected)
+		}
+		if opened, err := aead.Open(nil, nonce, expected, ad); err != nil || !bytes.Equal(opened, plain) {
+			t.Errorf("vector %d: Open == % 02x, %v, want % 02x", n + 1, opened, err, plain)
+		}
+	}
+}
+
+// ocbIterated is the iterated test of RFC 7253 appendix A, which exercises
+// every message and associated data length up to 127 bytes.
+func ocbIterated(block func([]byte) *ocb, tagSize int) []byte {
+	key := make([]byte, 16)
+	key[15] = byte(tagSize * 8)
+	o := block(key)
+
+	nonce := func(i int) []byte {
+		n := make([]byte, 12)
+		n[10], n[11] = byte(i >> 8), byte(i)
+		return n
+	}
+
+	var C []byte
+	for i := 0; i < 128; i++ {
+		S := make([]byte, i)
+		C = o.Seal(C, nonce(3 * i + 1), S, S)
+		C = o.Seal(C, nonce(3 * i + 2), S, nil)
+		C = o.Seal(C, nonce(3 * i + 3), nil, S)
+	}
+	return o.Seal(nil, nonce(385), nil, C)
+}
+
+func TestOCBIterated(t *testing.T) {
+	for _, v := range []struct {
+		tagSize 	int
+		output 		string
+	}{
+		{16, "67e944d23256c5e0b6c61fa22fdf1ea2"},
+		{12, "77a3d8e73589158d25d01209"},
+		{8, "192c9b7bd90ba06a"},
+	} {
+		output := ocbIterated(func(key []byte) *ocb {
+			block, _ := aes.NewCipher(key)
+			o, _ := NewOCBWithSizes(block, 12, v.tagSize)
+			return o.(*ocb)
+		}, v.tagSize)
+
+		if hex.EncodeToString(output) != v.output {
+			t.Errorf("AES OCB-TAGLEN%d iterated test == %x, want %s", v.tagSize * 8, output, v.output)
+		}
+	}
+}
+
+// TestOCBKnownAnswer pins the iterated test over RC5-64/12 to the output of
+// this package, which passes the AES vectors above.
+func TestOCBKnownAnswer(t *testing.T) {
+	output := ocbIterated(func(key []byte) *ocb {
+		block, _ := NewCipher64(key, 12)
+		o, _ := NewOCB(block)
+		return o.(*ocb)
+	}, 16)
+
+	if expected := "3521f0a6aaed487a881a09f7baa2fe95"; hex.EncodeToString(output) != expected {
+		t.Errorf("RC5-64 OCB iterated test == %x, want %s", output, expected)
+	}
+}
+
+func TestOCB(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher64(key, 12)
+
+	for _, sizes := range [][2]int{{12, 16}, {1, 8}, {15, 12}} {
+		aead, err := NewOCBWithSizes(block, sizes[0], sizes[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for size := 0; size < 100; size += 3 {
+			nonce := make([]byte, aead.NonceSize())
+			random.Read(nonce)
+			ad := make([]byte, random.Intn(40))
+			random.Read(ad)
+			value := make([]byte, size)
+			random.Read(value)
+
+			sealed := aead.Seal(nil, nonce, value, ad)
+			opened, err := aead.Open(nil, nonce, sealed, ad)
+			if err != nil || !bytes.Equal(opened, value) {
+				t.Errorf("Open(%d bytes) failed: %v", size, err)
+			}
+
+			sealed[random.Intn(len(sealed))] ^= 1 << uint(random.Intn(8))
+			if _, err := aead.Open(nil, nonce, sealed, ad); err != (AuthenticationError{}) {
+				t.Errorf("Open of tampered ciphertext error == %v", err)
+			}
+		}
+	}
+}
+
+func TestOCBBlockSize(t *testing.T) {
+	block, _ := NewCipher32(make([]byte, 16), 12)
+	if _, err := NewOCB(block); err != BlockSizeError(8) {
+		t.Errorf("NewOCB error == %v, want %v", err, BlockSizeError(8))
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// OFB-n, output feedback with n-bit segments for n a multiple of 8 up to the
+// block size: only the first segment of each output block is used as
+// keystream and fed back into the input block. Segments shorter than a block
+// give short keystream cycles and are only for reading old data.
+type ofb struct {
+	b 				cipher.Block 	// block cipher
+	reg 			[]byte 			// input block, the shift register
+	out 			[]byte 			// output block for the current segment
+	segmentSize 	int 			// segment size in bytes
+	pos 			int 			// bytes of the current segment done
+}
+
+// NewOFB returns a Stream which encrypts or decrypts with output feedback
+// mode in segments of segmentSize bytes, using the given Block. The length
+// of iv must be the same as the Block's block size.
+func NewOFB(b cipher.Block, iv []byte, segmentSize int) cipher.Stream {
+	bs := b.BlockSize()
+	if len(iv) != bs {
+		panic("rc5.NewOFB: IV length must equal block size")
+	}
+	if segmentSize < 1 || segmentSize > bs {
+		panic("rc5.NewOFB: segment size must be between 1 byte and the block size")
+	}
+
+	x := ofb{
+		b: b,
+		reg: make([]byte, bs),
+		out: make([]byte, bs),
+		segmentSize: segmentSize,
+	}
+	copy(x.reg, iv)
+	return &x
+}
+
+func (x *ofb) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	for i := 0; i < len(src); i++ {
+		if x.pos == 0 {
+			x.b.Encrypt(x.out, x.reg)
+			shiftIn(x.reg, x.out[:x.segmentSize])
+		}
+
+		dst[i] = src[i] ^ x.out[x.pos]
+
+		x.pos++
+		if x.pos == x.segmentSize {
+			x.pos = 0
+		}
+	}
+}
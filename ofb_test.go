@@ -0,0 +1,58 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"math/rand"
+	"testing"
+)
+
+func ofbStream(segmentSize int) func(cipher.Block, []byte) func(dst, src []byte) {
+	return func(b cipher.Block, iv []byte) func(dst, src []byte) {
+		return NewOFB(b, iv, segmentSize).XORKeyStream
+	}
+}
+
+// ofb8Reference xors each byte with the first byte of the encrypted shift
+// register, then shifts that output byte in.
+func ofb8Reference(b cipher.Block, iv, src []byte) []byte {
+	dst := make([]byte, len(src))
+	register := append([]byte(nil), iv...)
+	out := make([]byte, len(iv))
+	for i := range src {
+		b.Encrypt(out, register)
+		dst[i] = src[i] ^ out[0]
+		register = append(register[1:], out[0])
+	}
+	return dst
+}
+
+func TestOFB8(t *testing.T) {
+	testLegacyMode(t, "OFB8", 1, ofb8Reference, ofbStream(1), ofbStream(1), false)
+}
+
+func TestOFBFullBlock(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		block, _ := NewCipher(seqBytes(16), 12, wordSize)
+		bs := block.BlockSize()
+		iv := seqBytes(bs)
+		value := make([]byte, 5 * bs + 3)
+		random.Read(value)
+
+		// with whole block segments OFB-n is ordinary OFB
+		expected := make([]byte, len(value))
+		cipher.NewOFB(block, iv).XORKeyStream(expected, value)
+		encrypted := make([]byte, len(value))
+		NewOFB(block, iv, bs).XORKeyStream(encrypted, value)
+
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("OFB(%d) with whole block segments differs from cipher.NewOFB", wordSize)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"sync"
+)
+
+// smallest run of keystream, in bytes, worth handing to a goroutine
+const parallelChunk = 16 * 1024
+
+type parallelCTR struct {
+	b 				cipher.Block 	// block cipher
+	ctr 			[]byte 			// counter for the next keystream block
+	out 			[]byte 			// keystream of the last partial block
+	outUsed 		int 			// bytes of out already consumed
+	workers 		int 			// maximum number of goroutines
+}
+
+// NewParallelCTR returns a Stream which encrypts/decrypts using the given
+// Block in counter mode, spreading large calls to XORKeyStream across up to
+// workers goroutines. The counter is the whole block, incremented as a
+// big-endian integer, so the output is identical to cipher.NewCTR for any
+// sequence of calls. The length of iv must be the same as the Block's block
+// size.
+func NewParallelCTR(block cipher.Block, iv []byte, workers int) cipher.Stream {
+	if len(iv) != block.BlockSize() {
+		panic("rc5.NewParallelCTR: IV length must equal block size")
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctr := make([]byte, len(iv))
+	copy(ctr, iv)
+
+	return &parallelCTR{
+		b: block,
+		ctr: ctr,
+		out: make([]byte, len(iv)),
+		outUsed: len(iv),
+		workers: workers,
+	}
+}
+
+func (x *parallelCTR) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	// finish the keystream block left over from the previous call
+	if x.outUsed < len(x.out) {
+		n := xorBytes(dst, src, x.out[x.outUsed:])
+		x.outUsed += n
+		dst, src = dst[n:], src[n:]
+	}
+
+	bs := len(x.ctr)
+	blocks := len(src) / bs
+	if blocks > 0 {
+		x.xorBlocks(dst[:blocks * bs], src[:blocks * bs])
+		addCounter(x.ctr, uint64(blocks))
+		dst, src = dst[blocks * bs:], src[blocks * bs:]
+	}
+
+	if len(src) > 0 {
+		x.b.Encrypt(x.out, x.ctr)
+		addCounter(x.ctr, 1)
+		x.outUsed = xorBytes(dst, src, x.out)
+	}
+}
+
+// xorBlocks XORs whole blocks of keystream starting at x.ctr, splitting the
+// work evenly across goroutines once there is enough of it.
+func (x *parallelCTR) xorBlocks(dst, src []byte) {
+	bs := len(x.ctr)
+	blocks := len(src) / bs
+
+	workers := len(src) / parallelChunk
+	if workers > x.workers {
+		workers = x.workers
+	}
+	if workers <= 1 {
+		ctrKeyStream(x.b, x.ctr, dst, src)
+		return
+	}
+
+	share := (blocks + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < blocks; lo += share {
+		hi := lo + share
+		if hi > blocks {
+			hi = blocks
+		}
+
+		ctr := make([]byte, bs)
+		copy(ctr, x.ctr)
+		addCounter(ctr, uint64(lo))
+
+		wg.Add(1)
+		go func(ctr, dst, src []byte) {
+			defer wg.Done()
+			ctrKeyStream(x.b, ctr, dst, src)
+		}(ctr, dst[lo * bs:hi * bs], src[lo * bs:hi * bs])
+	}
+	wg.Wait()
+}
+
+// ctrKeyStream XORs src, a whole number of blocks, with the keystream
+// starting at counter iv into dst. iv is left untouched.
+func ctrKeyStream(b cipher.Block, iv []byte, dst, src []byte) {
+	bs := len(iv)
+	ctr := make([]byte, bs)
+	copy(ctr, iv)
+	ks := make([]byte, bs)
+
+	for len(src) > 0 {
+		b.Encrypt(ks, ctr)
+		addCounter(ctr, 1)
+		xorBytes(dst, src, ks)
+		dst, src = dst[bs:], src[bs:]
+	}
+}
+
+// addCounter adds n to the big-endian counter ctr, wrapping on overflow.
+func addCounter(ctr []byte, n uint64) {
+	for i := len(ctr) - 1; i >= 0 && n > 0; i-- {
+		s := uint64(ctr[i]) + n & 0xff
+		ctr[i] = byte(s)
+		n = n >> 8 + s >> 8
+	}
+}
+
+// xorBytes sets dst[i] = a[i] ^ b[i] for the shorter of a and b and returns
+// the number of bytes written.
+func xorBytes(dst, a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = a[i] ^ b[i]
+	}
+	return n
+}
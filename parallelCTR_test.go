@@ -0,0 +1,92 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestParallelCTR(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+
+		iv := make([]byte, block.BlockSize())
+		random.Read(iv)
+		// start near the top of the counter so the carry is exercised
+		for i := 1; i < len(iv); i++ {
+			iv[i] = 0xff
+		}
+
+		src := make([]byte, 5 * parallelChunk + 13)
+		random.Read(src)
+
+		expected := make([]byte, len(src))
+		cipher.NewCTR(block, iv).XORKeyStream(expected, src)
+
+		for _, workers := range []int{1, 3, 8} {
+			stream := NewParallelCTR(block, iv, workers)
+			encrypted := make([]byte, len(src))
+
+			for n := 0; n < len(src); {
+				m := n + random.Intn(3 * parallelChunk)
+				if m > len(src) {
+					m = len(src)
+				}
+				stream.XORKeyStream(encrypted[n:m], src[n:m])
+				n = m
+			}
+
+			if !bytes.Equal(encrypted, expected) {
+				t.Errorf("NewParallelCTR(%d, %d) differs from cipher.NewCTR", wordSize, workers)
+			}
+		}
+	}
+}
+
+func TestAddCounter(t *testing.T) {
+	ctr := []byte{0x00, 0xff, 0xff, 0xfe}
+	addCounter(ctr, 0x0102)
+	if expected := []byte{0x01, 0x00, 0x01, 0x00}; !bytes.Equal(ctr, expected) {
+		t.Errorf("addCounter == % 02x, want % 02x", ctr, expected)
+	}
+
+	ctr = []byte{0xff, 0xff}
+	addCounter(ctr, 1)
+	if expected := []byte{0x00, 0x00}; !bytes.Equal(ctr, expected) {
+		t.Errorf("addCounter == % 02x, want % 02x", ctr, expected)
+	}
+}
+
+func BenchmarkParallelCTR(b *testing.B) {
+	key := make([]byte, 16)
+	block, _ := NewCipher32(key, 12)
+	iv := make([]byte, block.BlockSize())
+	buf := make([]byte, 1 << 20)
+
+	for workers := 1; ; workers *= 2 {
+		if workers > runtime.NumCPU() {
+			workers = runtime.NumCPU()
+		}
+		stream := NewParallelCTR(block, iv, workers)
+		b.Run(strconv.Itoa(workers), func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				stream.XORKeyStream(buf, buf)
+			}
+		})
+		if workers == runtime.NumCPU() {
+			break
+		}
+	}
+}
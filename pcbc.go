@@ -0,0 +1,77 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// PCBC, propagating cipher block chaining, as used by Kerberos 4:
+// C_i = E(P_i ^ P_i-1 ^ C_i-1), with P_0 ^ C_0 = IV.
+
+type pcbcEncrypter cbc
+
+// NewPCBCEncrypter returns a BlockMode which encrypts in propagating cipher
+// block chaining mode, using the given Block. The length of iv must be the
+// same as the Block's block size.
+func NewPCBCEncrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != b.BlockSize() {
+		panic("rc5.NewPCBCEncrypter: IV length must equal block size")
+	}
+	return (*pcbcEncrypter)(newCBC(b, iv))
+}
+
+func (x *pcbcEncrypter) BlockSize() int { return x.blockSize }
+
+func (x *pcbcEncrypter) CryptBlocks(dst, src []byte) {
+	if len(src) % x.blockSize != 0 {
+		panic("rc5: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	bs := x.blockSize
+	for len(src) > 0 {
+		// keep P_i, which an in-place call is about to overwrite
+		copy(x.tmp, src[:bs])
+		xorBytes(dst[:bs], src, x.iv)
+		x.b.Encrypt(dst, dst[:bs])
+		xorBytes(x.iv, x.tmp, dst)
+		dst, src = dst[bs:], src[bs:]
+	}
+}
+
+type pcbcDecrypter cbc
+
+// NewPCBCDecrypter returns a BlockMode which decrypts in propagating cipher
+// block chaining mode, using the given Block. The length of iv must be the
+// same as the Block's block size.
+func NewPCBCDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+	if len(iv) != b.BlockSize() {
+		panic("rc5.NewPCBCDecrypter: IV length must equal block size")
+	}
+	return (*pcbcDecrypter)(newCBC(b, iv))
+}
+
+func (x *pcbcDecrypter) BlockSize() int { return x.blockSize }
+
+func (x *pcbcDecrypter) CryptBlocks(dst, src []byte) {
+	if len(src) % x.blockSize != 0 {
+		panic("rc5: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	bs := x.blockSize
+	for len(src) > 0 {
+		copy(x.tmp, src[:bs])
+		x.b.Decrypt(dst, src[:bs])
+		xorBytes(dst[:bs], dst, x.iv)
+		xorBytes(x.iv, x.tmp, dst)
+		dst, src = dst[bs:], src[bs:]
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"math/rand"
+	"testing"
+)
+
+func seqBytes(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+// testLegacyMode checks encryption against reference, the mode written out
+// block by block from the cipher, then round trips in one call and split over
+// two calls at every word size from 16 to 128 bits.
+func testLegacyMode(t *testing.T, name string, ivBlocks int, reference func(b cipher.Block, iv, src []byte) []byte,
+	encrypter, decrypter func(cipher.Block, []byte) func(dst, src []byte), blocksOnly bool) {
+	random := rand.New(rand.NewSource(99))
+	for _, wordSize := range []uint{16, 32, 64, 128} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, wordSize)
+		bs := block.BlockSize()
+
+		iv := make([]byte, ivBlocks * bs)
+		random.Read(iv)
+
+		for _, size := range []int{1, 2, 7} {
+			value := make([]byte, size * bs)
+			if !blocksOnly {
+				value = value[:len(value) - 1]
+			}
+			random.Read(value)
+
+			split := size / 2 * bs
+			encrypted := make([]byte, len(value))
+			encrypt := encrypter(block, iv)
+			encrypt(encrypted[:split], value[:split])
+			encrypt(encrypted[split:], value[split:])
+
+			if expected := reference(block, iv, value); !bytes.Equal(encrypted, expected) {
+				t.Errorf("%s(%d, %d bytes) == % 02x, want % 02x", name, wordSize, len(value), encrypted, expected)
+			}
+
+			decrypted := make([]byte, len(value))
+			copy(decrypted, encrypted)
+			decrypter(block, iv)(decrypted, decrypted)
+
+			if !bytes.Equal(decrypted, value) {
+				t.Errorf("%s(%d, %d bytes) round trip failed", name, wordSize, len(value))
+			}
+		}
+	}
+}
+
+func blockMode(f func(cipher.Block, []byte) cipher.BlockMode) func(cipher.Block, []byte) func(dst, src []byte) {
+	return func(b cipher.Block, iv []byte) func(dst, src []byte) {
+		return f(b, iv).CryptBlocks
+	}
+}
+
+// pcbcReference is C[i] = E(P[i] xor P[i-1] xor C[i-1]), with the IV in
+// place of P[0] xor C[0].
+func pcbcReference(b cipher.Block, iv, src []byte) []byte {
+	bs := b.BlockSize()
+	dst := make([]byte, len(src))
+	x := append([]byte(nil), iv...)
+	for i := 0; i < len(src); i += bs {
+		xorBytes(x, x, src[i:i + bs])
+		b.Encrypt(dst[i:i + bs], x)
+		xorBytes(x, src[i:i + bs], dst[i:i + bs])
+	}
+	return dst
+}
+
+func TestPCBC(t *testing.T) {
+	testLegacyMode(t, "PCBC", 1, pcbcReference, blockMode(NewPCBCEncrypter), blockMode(NewPCBCDecrypter), true)
+}
+
+func TestPCBCPropagation(t *testing.T) {
+	block, _ := NewCipher32(seqBytes(16), 12)
+	iv := seqBytes(8)
+	value := seqBytes(64)
+
+	encrypted := make([]byte, len(value))
+	NewPCBCEncrypter(block, iv).CryptBlocks(encrypted, value)
+	encrypted[8] ^= 1
+
+	// an error in one block garbles every block after it
+	decrypted := make([]byte, len(value))
+	NewPCBCDecrypter(block, iv).CryptBlocks(decrypted, encrypted)
+	if n := changedBlocks(decrypted, value, 8); n != 7 {
+		t.Errorf("a flipped ciphertext bit changed %d plaintext blocks, want 7", n)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+// PermuteUint32 encrypts x with b, which must be an RC5-16 cipher from
+// NewCipher16 or a 16-bit KeySchedule, as one 32-bit block: a keyed
+// permutation of uint32 values that does not allocate. The result is the
+// little-endian reading of b.Encrypt over the little-endian bytes of x.
+func PermuteUint32(b cipher.Block, x uint32) uint32 {
+	A, B := cipher16Of(b).encryptWords(uint16(x), uint16(x >> 16))
+	return uint32(A) | uint32(B) << 16
+}
+
+// UnpermuteUint32 inverts PermuteUint32.
+func UnpermuteUint32(b cipher.Block, x uint32) uint32 {
+	A, B := cipher16Of(b).decryptWords(uint16(x), uint16(x >> 16))
+	return uint32(A) | uint32(B) << 16
+}
+
+// PermuteUint64 encrypts x with b, which must be an RC5-32 cipher from
+// NewCipher32 or a 32-bit KeySchedule, as one 64-bit block: a keyed
+// permutation of uint64 values that does not allocate. The result is the
+// little-endian reading of b.Encrypt over the little-endian bytes of x.
+func PermuteUint64(b cipher.Block, x uint64) uint64 {
+	A, B := cipher32Of(b).encryptWords(uint32(x), uint32(x >> 32))
+	return uint64(A) | uint64(B) << 32
+}
+
+// UnpermuteUint64 inverts PermuteUint64.
+func UnpermuteUint64(b cipher.Block, x uint64) uint64 {
+	A, B := cipher32Of(b).decryptWords(uint32(x), uint32(x >> 32))
+	return uint64(A) | uint64(B) << 32
+}
+
+func cipher16Of(b cipher.Block) *cipher16 {
+	c, ok := b.(*cipher16)
+	if !ok {
+		panic("rc5: 32-bit permutation needs an RC5-16 cipher")
+	}
+	return c
+}
+
+func cipher32Of(b cipher.Block) *cipher32 {
+	c, ok := b.(*cipher32)
+	if !ok {
+		panic("rc5: 64-bit permutation needs an RC5-32 cipher")
+	}
+	return c
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestPermuteUint32(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher16(key, 12)
+
+	seen := make(map[uint32]bool)
+	for i := 0; i < 1000; i++ {
+		x := uint32(i)
+		if i % 2 == 1 {
+			x = random.Uint32()
+		}
+		y := PermuteUint32(block, x)
+
+		// the same permutation as the byte API
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], x)
+		block.Encrypt(buf[:], buf[:])
+		if expected := binary.LittleEndian.Uint32(buf[:]); y != expected {
+			t.Errorf("PermuteUint32(%#x) == %#x, want %#x", x, y, expected)
+		}
+		if back := UnpermuteUint32(block, y); back != x {
+			t.Errorf("UnpermuteUint32(PermuteUint32(%#x)) == %#x", x, back)
+		}
+		seen[y] = true
+	}
+	if len(seen) != 1000 {
+		t.Errorf("PermuteUint32 mapped 1000 inputs to %d outputs", len(seen))
+	}
+}
+
+func TestPermuteUint64(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher32(key, 12)
+
+	for i := 0; i < 1000; i++ {
+		x := uint64(i)
+		if i % 2 == 1 {
+			x = random.Uint64()
+		}
+		y := PermuteUint64(block, x)
+
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], x)
+		block.Encrypt(buf[:], buf[:])
+		if expected := binary.LittleEndian.Uint64(buf[:]); y != expected {
+			t.Errorf("PermuteUint64(%#x) == %#x, want %#x", x, y, expected)
+		}
+		if back := UnpermuteUint64(block, y); back != x {
+			t.Errorf("UnpermuteUint64(PermuteUint64(%#x)) == %#x", x, back)
+		}
+	}
+}
+
+func TestPermuteAllocs(t *testing.T) {
+	block16, _ := NewCipher16(seqBytes(16), 12)
+	block32, _ := NewCipher32(seqBytes(16), 12)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		x := PermuteUint32(block16, 12345)
+		UnpermuteUint32(block16, x)
+		y := PermuteUint64(block32, 12345)
+		UnpermuteUint64(block32, y)
+	})
+	if allocs != 0 {
+		t.Errorf("integer permutations made %v allocations, want 0", allocs)
+	}
+}
+
+func TestPermuteWrongCipher(t *testing.T) {
+	block, _ := NewCipher64(seqBytes(16), 12)
+	if !panics(func() { PermuteUint32(block, 1) }) {
+		t.Error("PermuteUint32 accepted an RC5-64 cipher")
+	}
+	if !panics(func() { PermuteUint64(block, 1) }) {
+		t.Error("PermuteUint64 accepted an RC5-64 cipher")
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"strconv"
+)
+
+// The RC5 mechanisms of PKCS #11 v2.40 section 2.12, as single-part
+// C_Encrypt, C_Decrypt, C_Sign and C_Verify calls over a raw key. Names and
+// values follow the spec so soft-token code reads like the C it stands in
+// for.
+
+// MechanismType is a PKCS #11 CK_MECHANISM_TYPE.
+type MechanismType uint
+
+const (
+	CKM_RC5_ECB 			MechanismType = 0x331
+	CKM_RC5_CBC 			MechanismType = 0x332
+	CKM_RC5_MAC 			MechanismType = 0x333
+	CKM_RC5_MAC_GENERAL 	MechanismType = 0x334
+	CKM_RC5_CBC_PAD 		MechanismType = 0x335
+)
+
+// RC5Params is CK_RC5_PARAMS, the parameter of CKM_RC5_ECB and CKM_RC5_MAC.
+type RC5Params struct {
+	Wordsize 		uint 			// ulWordsize, word size in bytes
+	Rounds 			uint 			// ulRounds, number of rounds
+}
+
+// RC5CBCParams is CK_RC5_CBC_PARAMS, the parameter of CKM_RC5_CBC and
+// CKM_RC5_CBC_PAD. The IV must be one block, twice the word size.
+type RC5CBCParams struct {
+	Wordsize 		uint 			// ulWordsize, word size in bytes
+	Rounds 			uint 			// ulRounds, number of rounds
+	IV 				[]byte 			// pIv and ulIvLen
+}
+
+// RC5MACGeneralParams is CK_RC5_MAC_GENERAL_PARAMS, the parameter of
+// CKM_RC5_MAC_GENERAL. MACLength is from 1 byte to one block.
+type RC5MACGeneralParams struct {
+	Wordsize 		uint 			// ulWordsize, word size in bytes
+	Rounds 			uint 			// ulRounds, number of rounds
+	MACLength 		uint 			// ulMacLength, MAC length in bytes
+}
+
+// Mechanism is CK_MECHANISM: a mechanism type and its parameter, one of
+// RC5Params, RC5CBCParams or RC5MACGeneralParams as the type requires.
+type Mechanism struct {
+	Mechanism 		MechanismType 	// mechanism
+	Parameter 		interface{} 	// pParameter
+}
+
+// CKRV is a PKCS #11 CK_RV return value other than CKR_OK, and is the error
+// returned by the Mechanism methods.
+type CKRV uint
+
+const (
+	CKR_DATA_LEN_RANGE 				CKRV = 0x21
+	CKR_ENCRYPTED_DATA_INVALID 		CKRV = 0x40
+	CKR_ENCRYPTED_DATA_LEN_RANGE 	CKRV = 0x41
+	CKR_KEY_SIZE_RANGE 				CKRV = 0x62
+	CKR_MECHANISM_INVALID 			CKRV = 0x70
+	CKR_MECHANISM_PARAM_INVALID 	CKRV = 0x71
+	CKR_SIGNATURE_INVALID 			CKRV = 0xc0
+	CKR_SIGNATURE_LEN_RANGE 		CKRV = 0xc1
+)
+
+var ckrvNames = map[CKRV]string{
+	CKR_DATA_LEN_RANGE: "CKR_DATA_LEN_RANGE",
+	CKR_ENCRYPTED_DATA_INVALID: "CKR_ENCRYPTED_DATA_INVALID",
+	CKR_ENCRYPTED_DATA_LEN_RANGE: "CKR_ENCRYPTED_DATA_LEN_RANGE",
+	CKR_KEY_SIZE_RANGE: "CKR_KEY_SIZE_RANGE",
+	CKR_MECHANISM_INVALID: "CKR_MECHANISM_INVALID",
+	CKR_MECHANISM_PARAM_INVALID: "CKR_MECHANISM_PARAM_INVALID",
+	CKR_SIGNATURE_INVALID: "CKR_SIGNATURE_INVALID",
+	CKR_SIGNATURE_LEN_RANGE: "CKR_SIGNATURE_LEN_RANGE",
+}
+
+func (r CKRV) Error() string {
+	if name, ok := ckrvNames[r]; ok {
+		return "scorpioncompute.com/rc5: " + name
+	}
+	return "scorpioncompute.com/rc5: CK_RV 0x" + strconv.FormatUint(uint64(r), 16)
+}
+
+// block keys the cipher for m, checking the word size, rounds and
+// key length ranges of the spec: words of 1 to 64 bytes, up to 255 rounds
+// and keys of up to 255 bytes.
+func (m Mechanism) block(key []byte) (cipher.Block, error) {
+	switch m.Mechanism {
+	case CKM_RC5_ECB, CKM_RC5_CBC, CKM_RC5_MAC, CKM_RC5_MAC_GENERAL, CKM_RC5_CBC_PAD:
+	default:
+		return nil, CKR_MECHANISM_INVALID
+	}
+
+	var wordsize, rounds uint
+	switch p := m.Parameter.(type) {
+	case RC5Params:
+		if m.Mechanism != CKM_RC5_ECB && m.Mechanism != CKM_RC5_MAC {
+			return nil, CKR_MECHANISM_PARAM_INVALID
+		}
+		wordsize, rounds = p.Wordsize, p.Rounds
+	case RC5CBCParams:
+		if m.Mechanism != CKM_RC5_CBC && m.Mechanism != CKM_RC5_CBC_PAD {
+			return nil, CKR_MECHANISM_PARAM_INVALID
+		}
+		if uint(len(p.IV)) != 2 * p.Wordsize {
+			return nil, CKR_MECHANISM_PARAM_INVALID
+		}
+		wordsize, rounds = p.Wordsize, p.Rounds
+	case RC5MACGeneralParams:
+		if m.Mechanism != CKM_RC5_MAC_GENERAL {
+			return nil, CKR_MECHANISM_PARAM_INVALID
+		}
+		if p.MACLength < 1 || p.MACLength > 2 * p.Wordsize {
+			return nil, CKR_MECHANISM_PARAM_INVALID
+		}
+		wordsize, rounds = p.Wordsize, p.Rounds
+	default:
+		return nil, CKR_MECHANISM_PARAM_INVALID
+	}
+
+	if wordsize < 1 || wordsize > 64 || rounds > 255 {
+		return nil, CKR_MECHANISM_PARAM_INVALID
+	}
+	if len(key) > 255 {
+		return nil, CKR_KEY_SIZE_RANGE
+	}
+	return NewCipher(key, rounds, 8 * wordsize)
+}
+
+// Encrypt is C_Encrypt with CKM_RC5_ECB, CKM_RC5_CBC or CKM_RC5_CBC_PAD.
+// Without padding the data must be a whole number of blocks.
+func (m Mechanism) Encrypt(key, data []byte) ([]byte, error) {
+	b, err := m.block(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := b.BlockSize()
+
+	switch m.Mechanism {
+	case CKM_RC5_ECB, CKM_RC5_CBC:
+		if len(data) % bs != 0 {
+			return nil, CKR_DATA_LEN_RANGE
+		}
+		out := make([]byte, len(data))
+		if m.Mechanism == CKM_RC5_CBC {
+			NewCBCEncrypter(b, m.Parameter.(RC5CBCParams).IV).CryptBlocks(out, data)
+			return out, nil
+		}
+		for i := 0; i < len(data); i += bs {
+			b.Encrypt(out[i:], data[i:i + bs])
+		}
+		return out, nil
+	case CKM_RC5_CBC_PAD:
+		out := make([]byte, len(data) / bs * bs + bs)
+		e := NewCBCPadEncrypter(b, m.Parameter.(RC5CBCParams).IV)
+		n := e.Update(out, data)
+		e.Final(out[n:])
+		return out, nil
+	}
+	return nil, CKR_MECHANISM_INVALID
+}
+
+// Decrypt is C_Decrypt with CKM_RC5_ECB, CKM_RC5_CBC or CKM_RC5_CBC_PAD.
+func (m Mechanism) Decrypt(key, data []byte) ([]byte, error) {
+	b, err := m.block(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := b.BlockSize()
+
+	switch m.Mechanism {
+	case CKM_RC5_ECB, CKM_RC5_CBC, CKM_RC5_CBC_PAD:
+		if len(data) % bs != 0 || m.Mechanism == CKM_RC5_CBC_PAD && len(data) == 0 {
+			return nil, CKR_ENCRYPTED_DATA_LEN_RANGE
+		}
+	default:
+		return nil, CKR_MECHANISM_INVALID
+	}
+
+	out := make([]byte, len(data))
+	switch m.Mechanism {
+	case CKM_RC5_ECB:
+		decryptBlocks(b, out, data)
+	case CKM_RC5_CBC:
+		NewCBCDecrypter(b, m.Parameter.(RC5CBCParams).IV).CryptBlocks(out, data)
+	case CKM_RC5_CBC_PAD:
+		d := NewCBCPadDecrypter(b, m.Parameter.(RC5CBCParams).IV)
+		n := d.Update(out, data)
+		f, err := d.Final(out[n:])
+		if err != nil {
+			return nil, CKR_ENCRYPTED_DATA_INVALID
+		}
+		out = out[:n + f]
+	}
+	return out, nil
+}
+
+// Sign is C_Sign with CKM_RC5_MAC, which gives half a block, or
+// CKM_RC5_MAC_GENERAL, which gives MACLength bytes: the leading bytes of
+// the CBC-MAC of the data padded with zero bytes to a whole block.
+func (m Mechanism) Sign(key, data []byte) ([]byte, error) {
+	b, err := m.block(key)
+	if err != nil {
+		return nil, err
+	}
+	bs := b.BlockSize()
+
+	var size int
+	switch m.Mechanism {
+	case CKM_RC5_MAC:
+		size = bs / 2
+	case CKM_RC5_MAC_GENERAL:
+		size = int(m.Parameter.(RC5MACGeneralParams).MACLength)
+	default:
+		return nil, CKR_MECHANISM_INVALID
+	}
+
+	// empty data is one block of zero padding
+	mac := make([]byte, bs)
+	for i := 0; i == 0 || i < len(data); i += bs {
+		xorBytes(mac, mac, data[i:])
+		b.Encrypt(mac, mac)
+	}
+	return mac[:size], nil
+}
+
+// Verify is C_Verify with CKM_RC5_MAC or CKM_RC5_MAC_GENERAL. It returns
+// nil only when signature is the MAC of data, compared in constant time.
+func (m Mechanism) Verify(key, data, signature []byte) error {
+	mac, err := m.Sign(key, data)
+	if err != nil {
+		return err
+	}
+	if len(signature) != len(mac) {
+		return CKR_SIGNATURE_LEN_RANGE
+	}
+	if subtle.ConstantTimeCompare(mac, signature) != 1 {
+		return CKR_SIGNATURE_INVALID
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestMechanismCBCVectors(t *testing.T) {
+	for n, v := range cbcVectors {
+		key, _ := hex.DecodeString(v.key)
+		iv, _ := hex.DecodeString(v.iv)
+		plain, _ := hex.DecodeString(v.plain)
+		expected, _ := hex.DecodeString(v.cipher)
+		m := Mechanism{CKM_RC5_CBC, RC5CBCParams{4, v.rounds, iv}}
+
+		encrypted, err := m.Encrypt(key, plain)
+		if err != nil || !bytes.Equal(encrypted, expected) {
+			t.Errorf("vector %d: CKM_RC5_CBC encrypt == % 02x, %v, want % 02x", n + 1, encrypted, err, expected)
+		}
+		decrypted, err := m.Decrypt(key, expected)
+		if err != nil || !bytes.Equal(decrypted, plain) {
+			t.Errorf("vector %d: CKM_RC5_CBC decrypt == % 02x, %v, want % 02x", n + 1, decrypted, err, plain)
+		}
+	}
+}
+
+func TestMechanismCBCPadAndMAC(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordsize := range []uint{2, 4, 8, 16} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, 8 * wordsize)
+		bs := block.BlockSize()
+		iv := make([]byte, bs)
+		random.Read(iv)
+
+		for _, size := range []int{0, 1, bs, 2 * bs + 3} {
+			data := make([]byte, size)
+			random.Read(data)
+
+			// CKM_RC5_CBC_PAD is CBC over the data padded as in PKCS #7
+			n := bs - size % bs
+			expected := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(n)}, n)...)
+			cipher.NewCBCEncrypter(block, iv).CryptBlocks(expected, expected)
+
+			m := Mechanism{CKM_RC5_CBC_PAD, RC5CBCParams{wordsize, 12, iv}}
+			encrypted, err := m.Encrypt(key, data)
+			if err != nil || !bytes.Equal(encrypted, expected) {
+				t.Errorf("CKM_RC5_CBC_PAD(%d byte words, %d bytes) == % 02x, %v, want % 02x", wordsize, size, encrypted, err, expected)
+			}
+			decrypted, err := m.Decrypt(key, expected)
+			if err != nil || !bytes.Equal(decrypted, data) {
+				t.Errorf("CKM_RC5_CBC_PAD(%d byte words, %d bytes) decrypt == % 02x, %v, want % 02x", wordsize, size, decrypted, err, data)
+			}
+
+			// the MAC is the last block of CBC from a zero IV over the data
+			// padded with zero bytes, at least one block of them when empty
+			padded := append([]byte(nil), data...)
+			for len(padded) == 0 || len(padded) % bs != 0 {
+				padded = append(padded, 0)
+			}
+			cipher.NewCBCEncrypter(block, make([]byte, bs)).CryptBlocks(padded, padded)
+			expected = padded[len(padded) - bs:]
+
+			for _, m := range []Mechanism{
+				{CKM_RC5_MAC, RC5Params{wordsize, 12}},
+				{CKM_RC5_MAC_GENERAL, RC5MACGeneralParams{wordsize, 12, 3}},
+			} {
+				want := expected[:bs / 2]
+				if m.Mechanism == CKM_RC5_MAC_GENERAL {
+					want = expected[:3]
+				}
+
+				mac, err := m.Sign(key, data)
+				if err != nil || !bytes.Equal(mac, want) {
+					t.Errorf("mechanism %#x(%d byte words, %d bytes) MAC == % 02x, %v, want % 02x", m.Mechanism, wordsize, size, mac, err, want)
+				}
+				if err := m.Verify(key, data, want); err != nil {
+					t.Errorf("mechanism %#x(%d byte words, %d bytes) rejected its own MAC: %v", m.Mechanism, wordsize, size, err)
+				}
+
+				bad := append([]byte(nil), want...)
+				bad[0] ^= 1
+				if err := m.Verify(key, data, bad); err != CKR_SIGNATURE_INVALID {
+					t.Errorf("mechanism %#x(%d byte words, %d bytes) verify of a bad MAC == %v, want CKR_SIGNATURE_INVALID", m.Mechanism, wordsize, size, err)
+				}
+				if err := m.Verify(key, data, want[1:]); err != CKR_SIGNATURE_LEN_RANGE {
+					t.Errorf("mechanism %#x(%d byte words, %d bytes) verify of a short MAC == %v, want CKR_SIGNATURE_LEN_RANGE", m.Mechanism, wordsize, size, err)
+				}
+			}
+		}
+	}
+}
+
+func TestMechanismECB(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+
+	for _, wordsize := range []uint{2, 4, 8, 16} {
+		key := make([]byte, 16)
+		random.Read(key)
+		block, _ := NewCipher(key, 12, 8 * wordsize)
+		bs := block.BlockSize()
+
+		data := make([]byte, 5 * bs)
+		random.Read(data)
+		expected := make([]byte, len(data))
+		for i := 0; i < len(data); i += bs {
+			block.Encrypt(expected[i:], data[i:i + bs])
+		}
+
+		m := Mechanism{CKM_RC5_ECB, RC5Params{wordsize, 12}}
+		encrypted, err := m.Encrypt(key, data)
+		if err != nil || !bytes.Equal(encrypted, expected) {
+			t.Errorf("CKM_RC5_ECB(%d byte words) failed: %v", wordsize, err)
+		}
+		decrypted, err := m.Decrypt(key, encrypted)
+		if err != nil || !bytes.Equal(decrypted, data) {
+			t.Errorf("CKM_RC5_ECB(%d byte words) round trip failed: %v", wordsize, err)
+		}
+
+		// CKM_RC5_MAC is the first half of CKM_RC5_MAC_GENERAL at full length
+		mac, _ := Mechanism{CKM_RC5_MAC, RC5Params{wordsize, 12}}.Sign(key, data[:bs + 1])
+		full, _ := Mechanism{CKM_RC5_MAC_GENERAL, RC5MACGeneralParams{wordsize, 12, uint(bs)}}.Sign(key, data[:bs + 1])
+		if len(mac) != bs / 2 || !bytes.Equal(mac, full[:bs / 2]) {
+			t.Errorf("CKM_RC5_MAC(%d byte words) == % 02x, want % 02x", wordsize, mac, full[:bs / 2])
+		}
+	}
+}
+
+func TestMechanismErrors(t *testing.T) {
+	key := seqBytes(16)
+	iv := seqBytes(8)
+
+	for _, v := range []struct {
+		m 				Mechanism
+		key 			[]byte
+		data 			[]byte
+		err 			error
+	}{
+		{Mechanism{0x330, RC5Params{4, 12}}, key, iv, CKR_MECHANISM_INVALID},
+		{Mechanism{CKM_RC5_ECB, nil}, key, iv, CKR_MECHANISM_PARAM_INVALID},
+		{Mechanism{CKM_RC5_ECB, RC5CBCParams{4, 12, iv}}, key, iv, CKR_MECHANISM_PARAM_INVALID},
+		{Mechanism{CKM_RC5_ECB, RC5Params{0, 12}}, key, iv, CKR_MECHANISM_PARAM_INVALID},
+		{Mechanism{CKM_RC5_ECB, RC5Params{4, 256}}, key, iv, CKR_MECHANISM_PARAM_INVALID},
+		{Mechanism{CKM_RC5_ECB, RC5Params{4, 12}}, make([]byte, 256), iv, CKR_KEY_SIZE_RANGE},
+		{Mechanism{CKM_RC5_ECB, RC5Params{4, 12}}, key, iv[:7], CKR_DATA_LEN_RANGE},
+		{Mechanism{CKM_RC5_CBC, RC5CBCParams{4, 12, iv[:4]}}, key, iv, CKR_MECHANISM_PARAM_INVALID},
+		{Mechanism{CKM_RC5_CBC, RC5CBCParams{4, 12, iv}}, key, iv[:7], CKR_DATA_LEN_RANGE},
+		{Mechanism{CKM_RC5_MAC, RC5Params{4, 12}}, key, iv, CKR_MECHANISM_INVALID},
+	} {
+		if _, err := v.m.Encrypt(v.key, v.data); err != v.err {
+			t.Errorf("Encrypt(%#x, %v) error == %v, want %v", v.m.Mechanism, v.m.Parameter, err, v.err)
+		}
+	}
+
+	m := Mechanism{CKM_RC5_CBC_PAD, RC5CBCParams{4, 12, iv}}
+	if _, err := m.Decrypt(key, nil); err != CKR_ENCRYPTED_DATA_LEN_RANGE {
+		t.Errorf("CKM_RC5_CBC_PAD decrypt of nothing error == %v, want CKR_ENCRYPTED_DATA_LEN_RANGE", err)
+	}
+	if _, err := m.Decrypt(key, iv); err != CKR_ENCRYPTED_DATA_INVALID {
+		t.Errorf("CKM_RC5_CBC_PAD decrypt of bad padding error == %v, want CKR_ENCRYPTED_DATA_INVALID", err)
+	}
+
+	for _, length := range []uint{0, 9} {
+		m = Mechanism{CKM_RC5_MAC_GENERAL, RC5MACGeneralParams{4, 12, length}}
+		if _, err := m.Sign(key, iv); err != CKR_MECHANISM_PARAM_INVALID {
+			t.Errorf("CKM_RC5_MAC_GENERAL with a %d byte MAC error == %v, want CKR_MECHANISM_PARAM_INVALID", length, err)
+		}
+		if err := m.Verify(key, iv, nil); err != CKR_MECHANISM_PARAM_INVALID {
+			t.Errorf("CKM_RC5_MAC_GENERAL verify with a %d byte MAC error == %v, want CKR_MECHANISM_PARAM_INVALID", length, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+)
+
+type selfTestVector struct {
+	name 			string 			// implementation under test
+	W 				uint 			// word size in bits
+	R 				uint 			// number of rounds
+	key 			string 			// hex encoded secret key
+	plain 			string 			// hex encoded plaintext block
+	cipher 			string 			// hex encoded ciphertext block
+}
+
+// Known answers from the RC5 test vectors of Kelsey, Schneier and Krovetz,
+// one per implementation.
+var selfTestVectors = []selfTestVector{
+	{"cipher16", 16, 16,
+		"0001020304050607",
+		"00010203",
+		"23a8d72e"},
+	{"cipher32", 32, 20,
+		"000102030405060708090a0b0c0d0e0f",
+		"0001020304050607",
+		"2a0edc0e9431ff73"},
+	{"cipher64", 64, 24,
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"000102030405060708090a0b0c0d0e0f",
+		"a46772820edbce0235abea32ae7178da"},
+	{"cipherBig", 128, 28,
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"eca5910921a4f4cfdd7ad7ad20a1fcba068ec7a7cd752d68fe914b7fe180b440"},
+}
+
+func init() {
+	if selfTestAtInit || os.Getenv("RC5_SELFTEST") != "" {
+		if err := SelfTest(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// SelfTest checks every implementation against a known answer in both
+// directions. The returned *SelfTestError names the first one that failed
+// and the stage it failed at.
+// It runs at package initialization when the RC5_SELFTEST environment
+// variable is set or the package is built with the rc5selftest tag.
+func SelfTest() error {
+	return selfTest(selfTestVectors)
+}
+
+func selfTest(vectors []selfTestVector) error {
+	for _, v := range vectors {
+		key, _ := hex.DecodeString(v.key)
+		plain, _ := hex.DecodeString(v.plain)
+		expected, _ := hex.DecodeString(v.cipher)
+
+		c, err := NewCipher(key, v.R, v.W)
+		if err != nil {
+			return &SelfTestError{v.name, "key setup"}
+		}
+
+		out := make([]byte, len(plain))
+		c.Encrypt(out, plain)
+		if !bytes.Equal(out, expected) {
+			return &SelfTestError{v.name, "encrypt"}
+		}
+
+		c.Decrypt(out, expected)
+		if !bytes.Equal(out, plain) {
+			return &SelfTestError{v.name, "decrypt"}
+		}
+
+		// the interleaved path used by CBC decryption
+		if d, ok := c.(blocksDecrypter); ok {
+			src := bytes.Repeat(expected, lanes + 1)
+			d.decryptBlocks(src, src)
+			if !bytes.Equal(src, bytes.Repeat(plain, lanes + 1)) {
+				return &SelfTestError{v.name, "decrypt"}
+			}
+		}
+	}
+	return nil
+}
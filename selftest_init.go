@@ -0,0 +1,12 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+//go:build rc5selftest
+// +build rc5selftest
+
+package rc5
+
+// selfTestAtInit makes package initialization run SelfTest, whether or not
+// RC5_SELFTEST is also set.
+const selfTestAtInit = true
@@ -0,0 +1,28 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSelfTestFailure(t *testing.T) {
+	for n := range selfTestVectors {
+		vectors := make([]selfTestVector, len(selfTestVectors))
+		copy(vectors, selfTestVectors)
+		vectors[n].cipher = vectors[n].plain
+
+		err, ok := selfTest(vectors).(*SelfTestError)
+		if !ok || err.Implementation != vectors[n].name || err.Stage != "encrypt" {
+			t.Errorf("selfTest with bad %s vector == %v", vectors[n].name, err)
+		}
+	}
+}
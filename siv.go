@@ -0,0 +1,146 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+const (
+	sivBlockSize = 16 				// S2V is defined over 128-bit blocks
+	sivMaxComponents = 126 			// associated data components S2V allows
+)
+
+// SIV is the deterministic authenticated encryption mode of RFC 5297 over
+// 128-bit blocks such as RC5-64: S2V, a CMAC based PRF of every input
+// component, gives the synthetic IV that is both tag and CTR counter.
+// Encrypting the same components twice gives the same ciphertext, so a
+// repeated nonce reveals only that two messages were equal.
+type SIV struct {
+	mac 			cipher.Block 	// K1, for S2V
+	ctr 			cipher.Block 	// K2, for CTR
+	nonceSize 		int 			// nonce size in bytes, 0 for none
+	k1 				[]byte 			// CMAC subkey for whole final blocks
+	k2 				[]byte 			// CMAC subkey for padded final blocks
+}
+
+// NewSIV returns an SIV keyed by two independent 128-bit Blocks. As an
+// AEAD, the nonce, when nonceSize is not zero, is the component after the
+// additional data.
+func NewSIV(mac, ctr cipher.Block, nonceSize int) (*SIV, error) {
+	if bs := mac.BlockSize(); bs != sivBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if bs := ctr.BlockSize(); bs != sivBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if nonceSize < 0 {
+		return nil, NonceSizeError(nonceSize)
+	}
+
+	k1, k2 := cmacSubkeys(mac)
+	return &SIV{mac, ctr, nonceSize, k1, k2}, nil
+}
+
+func (s *SIV) NonceSize() int { return s.nonceSize }
+
+func (s *SIV) Overhead() int { return sivBlockSize }
+
+func (s *SIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	return s.seal(dst, plaintext, s.components(nonce, additionalData))
+}
+
+func (s *SIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return s.OpenComponents(dst, ciphertext, s.components(nonce, additionalData)...)
+}
+
+func (s *SIV) components(nonce, additionalData []byte) [][]byte {
+	if len(nonce) != s.nonceSize {
+		panic("rc5: incorrect nonce length given to SIV")
+	}
+	if s.nonceSize == 0 {
+		return [][]byte{additionalData}
+	}
+	return [][]byte{additionalData, nonce}
+}
+
+// SealComponents encrypts and authenticates plaintext together with any
+// number of associated data components, appending the synthetic IV and then
+// the ciphertext to dst. RFC 5297 allows at most 126 components.
+func (s *SIV) SealComponents(dst, plaintext []byte, components ...[]byte) ([]byte, error) {
+	if len(components) > sivMaxComponents {
+		return nil, ComponentCountError(len(components))
+	}
+	return s.seal(dst, plaintext, components), nil
+}
+
+func (s *SIV) seal(dst, plaintext []byte, components [][]byte) []byte {
+	V := s.s2v(components, plaintext)
+
+	ret, out := sliceForAppend(dst, sivBlockSize + len(plaintext))
+	copy(out, V)
+	cipher.NewCTR(s.ctr, sivCounter(V)).XORKeyStream(out[sivBlockSize:], plaintext)
+	return ret
+}
+
+// OpenComponents reverses SealComponents, returning an AuthenticationError
+// and no plaintext if any input was modified.
+func (s *SIV) OpenComponents(dst, ciphertext []byte, components ...[]byte) ([]byte, error) {
+	if len(components) > sivMaxComponents {
+		return nil, ComponentCountError(len(components))
+	}
+	if len(ciphertext) < sivBlockSize {
+		return nil, AuthenticationError{}
+	}
+	V := ciphertext[:sivBlockSize]
+	ciphertext = ciphertext[sivBlockSize:]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	cipher.NewCTR(s.ctr, sivCounter(V)).XORKeyStream(out, ciphertext)
+
+	if subtle.ConstantTimeCompare(s.s2v(components, out), V) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, AuthenticationError{}
+	}
+	return ret, nil
+}
+
+// s2v is S2V(K1, S1, ..., Sn) of RFC 5297 section 2.4, with the plaintext
+// as the last string.
+func (s *SIV) s2v(components [][]byte, plaintext []byte) []byte {
+	D := cmacSum(s.mac, s.k1, s.k2, make([]byte, sivBlockSize))
+	for _, c := range components {
+		double(D, D)
+		xorBytes(D, D, cmacSum(s.mac, s.k1, s.k2, c))
+	}
+
+	var T []byte
+	if len(plaintext) >= sivBlockSize {
+		// T = Sn xorend D
+		T = make([]byte, len(plaintext))
+		copy(T, plaintext)
+		end := T[len(T) - sivBlockSize:]
+		xorBytes(end, end, D)
+	} else {
+		// T = dbl(D) xor pad(Sn)
+		double(D, D)
+		T = D
+		xorBytes(T, T, plaintext)
+		T[len(plaintext)] ^= 0x80
+	}
+	return cmacSum(s.mac, s.k1, s.k2, T)
+}
+
+// sivCounter is Q = V bitand 1^64 || 0^1 || 1^31 || 0^1 || 1^31.
+func sivCounter(V []byte) []byte {
+	Q := make([]byte, sivBlockSize)
+	copy(Q, V)
+	Q[8] &= 0x7f
+	Q[12] &= 0x7f
+	return Q
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestSIVVector(t *testing.T) {
+	// RFC 5297 appendix A.1, deterministic AES-SIV
+	k1, _ := hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0")
+	k2, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	ad, _ := hex.DecodeString("101112131415161718191a1b1c1d1e1f2021222324252627")
+	plain, _ := hex.DecodeString("112233445566778899aabbccddee")
+	expected, _ := hex.DecodeString("85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c")
+
+	mac, _ := aes.NewCipher(k1)
+	ctr, _ := aes.NewCipher(k2)
+	s, _ := NewSIV(mac, ctr, 0)
+
+	if sealed := s.Seal(nil, nil, plain, ad); !bytes.Equal(sealed, expected) {
+		t.Errorf("Seal == % 02x, want % 02x", sealed, expected)
+	}
+	if opened, err := s.Open(nil, nil, expected, ad); err != nil || !bytes.Equal(opened, plain) {
+		t.Errorf("Open == % 02x, %v, want % 02x", opened, err, plain)
+	}
+}
+
+func TestSIV(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 32)
+	random.Read(key)
+	mac, _ := NewCipher64(key[:16], 12)
+	ctr, _ := NewCipher64(key[16:], 12)
+	s, err := NewSIV(mac, ctr, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, 12)
+	random.Read(nonce)
+
+	for size := 0; size < 100; size += 3 {
+		components := make([][]byte, random.Intn(4))
+		for n := range components {
+			components[n] = make([]byte, random.Intn(40))
+			random.Read(components[n])
+		}
+		value := make([]byte, size)
+		random.Read(value)
+
+		sealed, _ := s.SealComponents(nil, value, components...)
+		opened, err := s.OpenComponents(nil, sealed, components...)
+		if err != nil || !bytes.Equal(opened, value) {
+			t.Errorf("OpenComponents(%d bytes) failed: %v", size, err)
+		}
+
+		sealed[random.Intn(len(sealed))] ^= 1 << uint(random.Intn(8))
+		if _, err := s.OpenComponents(nil, sealed, components...); err != (AuthenticationError{}) {
+			t.Errorf("OpenComponents of tampered ciphertext error == %v", err)
+		}
+
+		if len(components) > 0 {
+			sealed, _ = s.SealComponents(nil, value, components...)
+			if _, err := s.OpenComponents(nil, sealed, components[:len(components) - 1]...); err == nil {
+				t.Error("OpenComponents accepted a missing component")
+			}
+		}
+
+		// a repeated nonce gives equal ciphertexts for equal messages only
+		a := s.Seal(nil, nonce, value, nil)
+		b := s.Seal(nil, nonce, value, nil)
+		if !bytes.Equal(a, b) {
+			t.Errorf("Seal(%d bytes) is not deterministic", size)
+		}
+		other := append([]byte{1}, value...)
+		if c := s.Seal(nil, nonce, other, nil); bytes.Equal(c[:sivBlockSize], a[:sivBlockSize]) {
+			t.Errorf("Seal(%d bytes) gave the same SIV for different messages", size)
+		}
+	}
+}
+
+func TestSIVBlockSize(t *testing.T) {
+	block, _ := NewCipher32(make([]byte, 16), 12)
+	block64, _ := NewCipher64(make([]byte, 16), 12)
+	if _, err := NewSIV(block64, block, 0); err != BlockSizeError(8) {
+		t.Errorf("NewSIV error == %v, want %v", err, BlockSizeError(8))
+	}
+}
+
+func TestSIVComponentCount(t *testing.T) {
+	block, _ := NewCipher64(make([]byte, 16), 12)
+	s, _ := NewSIV(block, block, 0)
+
+	components := make([][]byte, sivMaxComponents + 1)
+	sealed, err := s.SealComponents(nil, nil, components[:sivMaxComponents]...)
+	if err != nil {
+		t.Fatalf("SealComponents with %d components failed: %v", sivMaxComponents, err)
+	}
+	if _, err := s.OpenComponents(nil, sealed, components[:sivMaxComponents]...); err != nil {
+		t.Errorf("OpenComponents with %d components failed: %v", sivMaxComponents, err)
+	}
+
+	if _, err := s.SealComponents(nil, nil, components...); err != ComponentCountError(len(components)) {
+		t.Errorf("SealComponents error == %v, want %v", err, ComponentCountError(len(components)))
+	}
+	if _, err := s.OpenComponents(nil, sealed, components...); err != ComponentCountError(len(components)) {
+		t.Errorf("OpenComponents error == %v, want %v", err, ComponentCountError(len(components)))
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Token is a permuted 64-bit ID, safe to show where the sequential ID it
+// hides is not. As text, in JSON and in a database it is base62, at most 11
+// characters of 0-9, A-Z and a-z.
+type Token uint64
+
+// NewToken returns the token for id under b, an RC5-32 cipher.
+func NewToken(b cipher.Block, id uint64) Token {
+	return Token(PermuteUint64(b, id))
+}
+
+// ParseToken parses the base62 text of a token.
+func ParseToken(s string) (Token, error) {
+	var t Token
+	err := t.UnmarshalText([]byte(s))
+	return t, err
+}
+
+// ID returns the ID t was made from under b.
+func (t Token) ID(b cipher.Block) uint64 {
+	return UnpermuteUint64(b, uint64(t))
+}
+
+func (t Token) String() string {
+	var buf [11]byte
+	return string(t.appendText(buf[:0]))
+}
+
+// appendText appends the base62 digits of t, most significant first.
+func (t Token) appendText(dst []byte) []byte {
+	var buf [11]byte
+	i := len(buf)
+	for v := uint64(t); ; v /= 62 {
+		i--
+		buf[i] = base62[v % 62]
+		if v < 62 {
+			break
+		}
+	}
+	return append(dst, buf[i:]...)
+}
+
+func (t Token) MarshalText() ([]byte, error) {
+	return t.appendText(nil), nil
+}
+
+func (t *Token) UnmarshalText(text []byte) error {
+	if len(text) == 0 || len(text) > 11 {
+		return TokenError(text)
+	}
+
+	var v uint64
+	for _, c := range text {
+		var d uint64
+		switch {
+		case '0' <= c && c <= '9':
+			d = uint64(c - '0')
+		case 'A' <= c && c <= 'Z':
+			d = uint64(c - 'A') + 10
+		case 'a' <= c && c <= 'z':
+			d = uint64(c - 'a') + 36
+		default:
+			return TokenError(text)
+		}
+
+		// 62 * v + d must not pass 2^64 - 1
+		if v > (^uint64(0) - d) / 62 {
+			return TokenError(text)
+		}
+		v = 62 * v + d
+	}
+
+	*t = Token(v)
+	return nil
+}
+
+func (t Token) MarshalJSON() ([]byte, error) {
+	b := append(make([]byte, 0, 13), '"')
+	return append(t.appendText(b), '"'), nil
+}
+
+// UnmarshalJSON leaves t unchanged for JSON null, as encoding/json does.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	s, err := strconv.Unquote(string(data))
+	if err != nil || len(data) == 0 || data[0] != '"' {
+		return TokenError(data)
+	}
+	return t.UnmarshalText([]byte(s))
+}
+
+// Value stores t as its base62 text.
+func (t Token) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+// Scan reads a token stored as base62 text, as a string or []byte.
+func (t *Token) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return t.UnmarshalText([]byte(v))
+	case []byte:
+		return t.UnmarshalText(v)
+	}
+	return TokenError(fmt.Sprint(src))
+}
@@ -0,0 +1,95 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+var (
+	_ json.Marshaler = Token(0)
+	_ json.Unmarshaler = (*Token)(nil)
+	_ encoding.TextMarshaler = Token(0)
+	_ encoding.TextUnmarshaler = (*Token)(nil)
+	_ driver.Valuer = Token(0)
+	_ sql.Scanner = (*Token)(nil)
+)
+
+func TestTokenText(t *testing.T) {
+	for _, v := range []struct {
+		token 			Token
+		text 			string
+	}{
+		{0, "0"},
+		{61, "z"},
+		{62, "10"},
+		{3843, "zz"},
+		{1 << 63, "AzL8n0Y58m8"},
+		{^Token(0), "LygHa16AHYF"},
+	} {
+		if s := v.token.String(); s != v.text {
+			t.Errorf("Token(%d).String() == %q, want %q", uint64(v.token), s, v.text)
+		}
+		if token, err := ParseToken(v.text); err != nil || token != v.token {
+			t.Errorf("ParseToken(%q) == %d, %v, want %d", v.text, uint64(token), err, uint64(v.token))
+		}
+	}
+
+	for _, s := range []string{"", "LygHa16AHYG", "zzzzzzzzzzz", "000000000000", "12-4", "é"} {
+		if _, err := ParseToken(s); err != TokenError(s) {
+			t.Errorf("ParseToken(%q) error == %v, want TokenError", s, err)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher32(key, 12)
+
+	for id := uint64(1); id < 100; id++ {
+		token := NewToken(block, id)
+		if token.ID(block) != id {
+			t.Errorf("NewToken(%d).ID() == %d", id, token.ID(block))
+		}
+
+		data, err := json.Marshal(map[string]Token{"id": token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded map[string]Token
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded["id"] != token {
+			t.Errorf("JSON round trip of %s through %s == %s, %v", token, data, decoded["id"], err)
+		}
+
+		value, _ := token.Value()
+		var scanned Token
+		if err := scanned.Scan(value); err != nil || scanned != token {
+			t.Errorf("Scan(Value()) of %s == %s, %v", token, scanned, err)
+		}
+		if err := scanned.Scan([]byte(token.String())); err != nil || scanned != token {
+			t.Errorf("Scan([]byte) of %s == %s, %v", token, scanned, err)
+		}
+	}
+
+	var token Token
+	if err := json.Unmarshal([]byte("12345"), &token); err == nil {
+		t.Error("a JSON number unmarshalled into a Token")
+	}
+
+	token = Token(12345)
+	if err := json.Unmarshal([]byte("null"), &token); err != nil || token != Token(12345) {
+		t.Errorf("JSON null unmarshalled into a Token == %s, %v, want it unchanged", token, err)
+	}
+	if err := token.Scan(int64(5)); err == nil {
+		t.Error("an int64 scanned into a Token")
+	}
+}
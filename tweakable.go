@@ -0,0 +1,143 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// TweakableBlock is a block cipher with a public tweak: each tweak selects
+// a different permutation of blocks under the same key.
+type TweakableBlock interface {
+	// BlockSize returns the cipher's block size.
+	BlockSize() int
+
+	// TweakSize returns the length of every tweak.
+	TweakSize() int
+
+	// Encrypt encrypts the first block in src into dst under tweak.
+	// Dst and src must overlap entirely or not at all.
+	Encrypt(dst, src, tweak []byte)
+
+	// Decrypt decrypts the first block in src into dst under tweak.
+	// Dst and src must overlap entirely or not at all.
+	Decrypt(dst, src, tweak []byte)
+}
+
+type xex XTS
+
+// NewXEX returns Rogaway's XEX construction over two 128-bit block ciphers
+// such as RC5-64: E(P ^ D) ^ D, with the mask D = E'(N) * x^j in GF(2^128).
+// The tweak is 16 bytes, the nonce N then the block index j, each a
+// little-endian 64-bit number, so that XEX with N the sector number is
+// block j of an XTS sector under the same two keys.
+func NewXEX(data, tweak cipher.Block) (TweakableBlock, error) {
+	x, err := newXTS(data, tweak)
+	if err != nil {
+		return nil, err
+	}
+	return (*xex)(x), nil
+}
+
+func (x *xex) BlockSize() int { return xtsBlockSize }
+
+func (x *xex) TweakSize() int { return xtsBlockSize }
+
+func (x *xex) Encrypt(dst, src, tweak []byte) {
+	(*XTS)(x).cryptBlock(dst[:xtsBlockSize], src[:xtsBlockSize], x.mask(tweak), true)
+}
+
+func (x *xex) Decrypt(dst, src, tweak []byte) {
+	(*XTS)(x).cryptBlock(dst[:xtsBlockSize], src[:xtsBlockSize], x.mask(tweak), false)
+}
+
+// mask is E'(N) * x^j, raising x to the j by squaring.
+func (x *xex) mask(tweak []byte) []byte {
+	if len(tweak) != xtsBlockSize {
+		panic("rc5: incorrect tweak length given to XEX")
+	}
+
+	mask := make([]byte, xtsBlockSize)
+	copy(mask, tweak[:8])
+	x.k2.Encrypt(mask, mask)
+
+	j := binary.LittleEndian.Uint64(tweak[8:])
+	power := make([]byte, xtsBlockSize)
+	power[0] = 2
+	for ; j > 0; j >>= 1 {
+		if j & 1 == 1 {
+			mulGF128(mask, mask, power)
+		}
+		if j > 1 {
+			mulGF128(power, power, power)
+		}
+	}
+	return mask
+}
+
+// mulGF128 sets dst to a * b in GF(2^128), in the little-endian order mul2
+// uses. dst may alias a or b.
+func mulGF128(dst, a, b []byte) {
+	var z, v [xtsBlockSize]byte
+	copy(v[:], a)
+	for k := 0; k < 8 * xtsBlockSize; k++ {
+		if b[k / 8] >> uint(k % 8) & 1 == 1 {
+			xorBytes(z[:], z[:], v[:])
+		}
+		mul2(v[:])
+	}
+	copy(dst, z[:])
+}
+
+type encryptedTweak struct {
+	data 			cipher.Block 	// block cipher for the data
+	tweak 			cipher.Block 	// block cipher for the tweak
+}
+
+// NewEncryptedTweak returns the encrypt-the-tweak construction over two
+// block ciphers with 32- or 64-bit blocks, such as RC5-16 and RC5-32, that
+// have no doubling worth using: E(P ^ D) ^ D with the mask D = E'(T) for a
+// tweak T of one block.
+func NewEncryptedTweak(data, tweak cipher.Block) (TweakableBlock, error) {
+	bs := data.BlockSize()
+	if bs != 4 && bs != 8 {
+		return nil, BlockSizeError(bs)
+	}
+	if tbs := tweak.BlockSize(); tbs != bs {
+		return nil, BlockSizeError(tbs)
+	}
+	return &encryptedTweak{data, tweak}, nil
+}
+
+func (e *encryptedTweak) BlockSize() int { return e.data.BlockSize() }
+
+func (e *encryptedTweak) TweakSize() int { return e.data.BlockSize() }
+
+func (e *encryptedTweak) Encrypt(dst, src, tweak []byte) {
+	mask := e.mask(tweak)
+	bs := len(mask)
+	xorBytes(dst[:bs], src, mask)
+	e.data.Encrypt(dst, dst[:bs])
+	xorBytes(dst[:bs], dst, mask)
+}
+
+func (e *encryptedTweak) Decrypt(dst, src, tweak []byte) {
+	mask := e.mask(tweak)
+	bs := len(mask)
+	xorBytes(dst[:bs], src, mask)
+	e.data.Decrypt(dst, dst[:bs])
+	xorBytes(dst[:bs], dst, mask)
+}
+
+func (e *encryptedTweak) mask(tweak []byte) []byte {
+	bs := e.data.BlockSize()
+	if len(tweak) != bs {
+		panic("rc5: incorrect tweak length given to encrypt-the-tweak")
+	}
+	mask := make([]byte, bs)
+	e.tweak.Encrypt(mask, tweak)
+	return mask
+}
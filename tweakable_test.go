@@ -0,0 +1,147 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestXEXMatchesXTS(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 32)
+	random.Read(key)
+	data, _ := NewCipher64(key[:16], 20)
+	tweak, _ := NewCipher64(key[16:], 20)
+	x, _ := NewXTS(key[:16], key[16:], 20)
+	tb, _ := NewXEX(data, tweak)
+
+	sector := make([]byte, 512)
+	random.Read(sector)
+	encrypted := make([]byte, len(sector))
+	x.EncryptSector(encrypted, sector, 0x123456789a)
+
+	// block j of an XTS sector is XEX with the tweak (sector number, j)
+	for j := 0; j < len(sector) / 16; j++ {
+		tw := make([]byte, 16)
+		binary.LittleEndian.PutUint64(tw, 0x123456789a)
+		binary.LittleEndian.PutUint64(tw[8:], uint64(j))
+
+		block := make([]byte, 16)
+		tb.Encrypt(block, sector[16 * j:], tw)
+		if !bytes.Equal(block, encrypted[16 * j:16 * j + 16]) {
+			t.Errorf("XEX block %d == % 02x, want % 02x", j, block, encrypted[16 * j:16 * j + 16])
+		}
+	}
+}
+
+func TestXEXMask(t *testing.T) {
+	data, _ := NewCipher64(seqBytes(16), 20)
+	tweak, _ := NewCipher64(seqBytes(32)[16:], 20)
+	tb, _ := NewXEX(data, tweak)
+
+	// x^300 by squaring against three hundred doublings
+	tw := seqBytes(16)
+	binary.LittleEndian.PutUint64(tw[8:], 300)
+	expected := make([]byte, 16)
+	copy(expected, tw[:8])
+	tweak.Encrypt(expected, expected)
+	for i := 0; i < 300; i++ {
+		mul2(expected)
+	}
+
+	if mask := tb.(*xex).mask(tw); !bytes.Equal(mask, expected) {
+		t.Errorf("XEX mask for j = 300 == % 02x, want % 02x", mask, expected)
+	}
+}
+
+// testTweakable round trips random blocks and checks that two tweaks give
+// unrelated permutations: the outputs for the same input should differ by
+// a different amount for almost every input, where related permutations
+// would show a fixed difference.
+func testTweakable(t *testing.T, name string, tb TweakableBlock) {
+	random := rand.New(rand.NewSource(99))
+	bs, ts := tb.BlockSize(), tb.TweakSize()
+
+	tweaks := make([][]byte, 8)
+	for i := range tweaks {
+		tweaks[i] = make([]byte, ts)
+		random.Read(tweaks[i])
+	}
+	// tweaks one bit apart too
+	tweaks[1] = append([]byte(nil), tweaks[0]...)
+	tweaks[1][ts - 1] ^= 1
+
+	const inputs = 256
+	outputs := make([][]byte, len(tweaks))
+	for i, tweak := range tweaks {
+		outputs[i] = make([]byte, inputs * bs)
+		src := rand.New(rand.NewSource(1))
+		for n := 0; n < inputs; n++ {
+			block := make([]byte, bs)
+			src.Read(block)
+
+			out := outputs[i][n * bs:(n + 1) * bs]
+			tb.Encrypt(out, block, tweak)
+			back := make([]byte, bs)
+			tb.Decrypt(back, out, tweak)
+			if !bytes.Equal(back, block) {
+				t.Fatalf("%s round trip failed", name)
+			}
+		}
+	}
+
+	for i := range tweaks {
+		for j := i + 1; j < len(tweaks); j++ {
+			differences := make(map[string]bool)
+			equal := 0
+			for n := 0; n < inputs; n++ {
+				a := outputs[i][n * bs:(n + 1) * bs]
+				b := outputs[j][n * bs:(n + 1) * bs]
+				if bytes.Equal(a, b) {
+					equal++
+				}
+				d := make([]byte, bs)
+				xorBytes(d, a, b)
+				differences[string(d)] = true
+			}
+			if equal > 1 || len(differences) < inputs - 1 {
+				t.Errorf("%s tweaks %d and %d: %d equal outputs, %d distinct differences of %d", name, i, j, equal, len(differences), inputs)
+			}
+		}
+	}
+}
+
+func TestTweakable(t *testing.T) {
+	key := seqBytes(32)
+
+	data64, _ := NewCipher64(key[:16], 20)
+	tweak64, _ := NewCipher64(key[16:], 20)
+	xex64, err := NewXEX(data64, tweak64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTweakable(t, "XEX", xex64)
+
+	for _, wordSize := range []uint{16, 32} {
+		data, _ := NewCipher(key[:16], 12, wordSize)
+		tweak, _ := NewCipher(key[16:], 12, wordSize)
+		tb, err := NewEncryptedTweak(data, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTweakable(t, "encrypt-the-tweak", tb)
+	}
+
+	data32, _ := NewCipher32(key, 12)
+	if _, err := NewXEX(data32, data32); err != BlockSizeError(8) {
+		t.Errorf("NewXEX(64-bit block) error == %v, want BlockSizeError", err)
+	}
+	if _, err := NewEncryptedTweak(data64, tweak64); err != BlockSizeError(16) {
+		t.Errorf("NewEncryptedTweak(128-bit block) error == %v, want BlockSizeError", err)
+	}
+}
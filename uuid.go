@@ -0,0 +1,145 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+	"encoding/hex"
+)
+
+// uuidBits is the number of UUID bits outside the version and variant.
+const uuidBits = 122
+
+// UUID is a 16 byte universally unique identifier, as in RFC 4122. As text
+// it is the canonical form, 8-4-4-4-12 lower case hex digits.
+type UUID [16]byte
+
+// ParseUUID parses the canonical text of a UUID, in either case.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	err := u.UnmarshalText([]byte(s))
+	return u, err
+}
+
+func (u UUID) String() string {
+	text, _ := u.MarshalText()
+	return string(text)
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	text := make([]byte, 36)
+	hex.Encode(text, u[:4])
+	text[8] = '-'
+	hex.Encode(text[9:], u[4:6])
+	text[13] = '-'
+	hex.Encode(text[14:], u[6:8])
+	text[18] = '-'
+	hex.Encode(text[19:], u[8:10])
+	text[23] = '-'
+	hex.Encode(text[24:], u[10:])
+	return text, nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	if len(text) != 36 || text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+		return UUIDError(text)
+	}
+
+	// the five hex groups, between the dashes at 8, 13, 18 and 23
+	var v UUID
+	j := 0
+	for _, g := range [5][2]int{{0, 8}, {9, 13}, {14, 18}, {19, 23}, {24, 36}} {
+		n, err := hex.Decode(v[j:], text[g[0]:g[1]])
+		if err != nil {
+			return UUIDError(text)
+		}
+		j += n
+	}
+	*u = v
+	return nil
+}
+
+// Version returns the version number of u, its top four bits of byte 6.
+func (u UUID) Version() int { return int(u[6] >> 4) }
+
+// UUIDCipher encrypts UUIDs keeping their version and variant: the version
+// nibble and the top two bits of byte 8 are left alone and the other 122
+// bits are encrypted with FF1 in radix 2. A valid UUID of any version, such
+// as a UUIDv7 whose leading bits give away when it was made, encrypts to a
+// valid UUID of the same version whose other bits reveal nothing.
+type UUIDCipher struct {
+	f 				*FF1 			// radix 2 FF1 on the 122 free bits
+}
+
+// NewUUIDCipher returns a UUIDCipher over b, which must have 128-bit blocks
+// such as RC5-64.
+func NewUUIDCipher(b cipher.Block) (*UUIDCipher, error) {
+	f, err := NewFF1WithAlphabet(b, "01", uuidBits, uuidBits, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UUIDCipher{f}, nil
+}
+
+// Encrypt encrypts u.
+func (c *UUIDCipher) Encrypt(u UUID) UUID {
+	return c.crypt(u, true)
+}
+
+// Decrypt inverts Encrypt.
+func (c *UUIDCipher) Decrypt(u UUID) UUID {
+	return c.crypt(u, false)
+}
+
+// EncryptUUID encrypts u under b, which must have 128-bit blocks such as
+// RC5-64, as a UUIDCipher does. Encrypting many UUIDs under one key is
+// cheaper through a UUIDCipher.
+func EncryptUUID(b cipher.Block, u UUID) (UUID, error) {
+	c, err := NewUUIDCipher(b)
+	if err != nil {
+		return UUID{}, err
+	}
+	return c.Encrypt(u), nil
+}
+
+// DecryptUUID inverts EncryptUUID.
+func DecryptUUID(b cipher.Block, u UUID) (UUID, error) {
+	c, err := NewUUIDCipher(b)
+	if err != nil {
+		return UUID{}, err
+	}
+	return c.Decrypt(u), nil
+}
+
+func (c *UUIDCipher) crypt(u UUID, encrypt bool) UUID {
+	// the bits of u, most significant first, without bits 48 to 51 and
+	// 64 and 65
+	X := make([]uint16, 0, uuidBits)
+	for i := 0; i < 128; i++ {
+		if uuidKept(i) {
+			continue
+		}
+		X = append(X, uint16(u[i / 8] >> uint(7 - i % 8) & 1))
+	}
+
+	Y := c.f.crypt(X, nil, encrypt)
+
+	v := u
+	for i := 0; i < 128; i++ {
+		if uuidKept(i) {
+			continue
+		}
+		bit := byte(1) << uint(7 - i % 8)
+		v[i / 8] = v[i / 8] &^ bit | byte(Y[0]) * bit
+		Y = Y[1:]
+	}
+	return v
+}
+
+// uuidKept reports whether bit i of a UUID, counting from the most
+// significant bit of byte 0, is a version or variant bit.
+func uuidKept(i int) bool {
+	return 48 <= i && i < 52 || i == 64 || i == 65
+}
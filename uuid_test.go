@@ -0,0 +1,152 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestUUIDText(t *testing.T) {
+	const text = "017f22e2-79b0-7cc3-98c4-dc0c0c07398f"
+	u, err := ParseUUID(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u[0] != 0x01 || u[15] != 0x8f || u.Version() != 7 {
+		t.Errorf("ParseUUID(%q) == % 02x", text, u[:])
+	}
+	if s := u.String(); s != text {
+		t.Errorf("UUID.String() == %q, want %q", s, text)
+	}
+	if upper, err := ParseUUID("017F22E2-79B0-7CC3-98C4-DC0C0C07398F"); err != nil || upper != u {
+		t.Errorf("ParseUUID of upper case == %s, %v", upper, err)
+	}
+
+	for _, s := range []string{
+		"",
+		"017f22e279b07cc398c4dc0c0c07398f",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398fa",
+		"017f22e2+79b0-7cc3-98c4-dc0c0c07398f",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398g",
+		"017f22e2-79b-07cc3-98c4-dc0c0c07398f",
+		"0F7f22e2-7Fb0-7cc3-98c4-dc0c0c0739-f",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c0739-8",
+		"-17f22e2-79b0-7cc3-98c4-dc0c0c07398f",
+		"017f22e2-79b0--cc3-98c4-dc0c0c07398f",
+		"017f22e2-79b0-7cc3-98c4--c0c0c07398f",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398-",
+	} {
+		if _, err := ParseUUID(s); err != UUIDError(s) {
+			t.Errorf("ParseUUID(%q) error == %v, want UUIDError", s, err)
+		}
+	}
+}
+
+func TestUUIDCipherFF1(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher64(key, 20)
+	c, _ := NewUUIDCipher(block)
+	f, _ := NewFF1(block, 2, uuidBits, uuidBits, nil)
+
+	for i := 0; i < 20; i++ {
+		var u UUID
+		random.Read(u[:])
+
+		// the 122 free bits as a radix 2 numeral string, through FF1 directly
+		bits := ""
+		for _, b := range u {
+			bits += fmt.Sprintf("%08b", b)
+		}
+		free := bits[:48] + bits[52:64] + bits[66:]
+		encrypted, err := f.Encrypt(free)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bits = encrypted[:48] + bits[48:52] + encrypted[48:60] + bits[64:66] + encrypted[60:]
+		var expected UUID
+		for n := range expected {
+			b, _ := strconv.ParseUint(bits[8 * n:8 * n + 8], 2, 8)
+			expected[n] = byte(b)
+		}
+
+		if e := c.Encrypt(u); e != expected {
+			t.Errorf("UUIDCipher.Encrypt(%s) == %s, want %s", u, e, expected)
+		}
+		if d := c.Decrypt(expected); d != u {
+			t.Errorf("UUIDCipher.Decrypt(%s) == %s, want %s", expected, d, u)
+		}
+	}
+}
+
+func TestUUIDCipher(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key := make([]byte, 16)
+	random.Read(key)
+	block, _ := NewCipher64(key, 20)
+	c, err := NewUUIDCipher(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// consecutive UUIDv7s from one millisecond
+	var u UUID
+	random.Read(u[:])
+	u[0], u[1], u[2], u[3], u[4], u[5] = 0x01, 0x7f, 0x22, 0xe2, 0x79, 0xb0
+	u[6] = 0x70 | u[6] & 0x0f
+	u[8] = 0x80 | u[8] & 0x3f
+
+	seen := make(map[UUID]bool)
+	for i := 0; i < 200; i++ {
+		u[15] = byte(i)
+		encrypted := c.Encrypt(u)
+
+		if encrypted.Version() != 7 || encrypted[8] >> 6 != 2 {
+			t.Errorf("Encrypt(%s) == %s lost its version or variant", u, encrypted)
+		}
+		if encrypted[0] == u[0] && encrypted[1] == u[1] && encrypted[2] == u[2] {
+			t.Errorf("Encrypt(%s) == %s kept the timestamp", u, encrypted)
+		}
+		if seen[encrypted] {
+			t.Errorf("Encrypt(%s) == %s repeats an output", u, encrypted)
+		}
+		seen[encrypted] = true
+
+		if decrypted := c.Decrypt(encrypted); decrypted != u {
+			t.Errorf("Decrypt(Encrypt(%s)) == %s", u, decrypted)
+		}
+	}
+
+	// the version and variant bits pass through whatever they are
+	var zero UUID
+	zero[6], zero[8] = 0xf0, 0xc0
+	if encrypted := c.Encrypt(zero); encrypted[6] >> 4 != 0xf || encrypted[8] >> 6 != 3 {
+		t.Errorf("Encrypt(%s) == %s", zero, encrypted)
+	}
+
+	// EncryptUUID and DecryptUUID are one-off UUIDCiphers
+	if encrypted, err := EncryptUUID(block, u); err != nil || encrypted != c.Encrypt(u) {
+		t.Errorf("EncryptUUID(%s) == %s, %v, want %s", u, encrypted, err, c.Encrypt(u))
+	}
+	if decrypted, err := DecryptUUID(block, c.Encrypt(u)); err != nil || decrypted != u {
+		t.Errorf("DecryptUUID(%s) == %s, %v, want %s", c.Encrypt(u), decrypted, err, u)
+	}
+
+	small, _ := NewCipher32(key, 12)
+	if _, err := NewUUIDCipher(small); err != BlockSizeError(8) {
+		t.Errorf("NewUUIDCipher with a 64-bit block error == %v, want BlockSizeError", err)
+	}
+	if _, err := EncryptUUID(small, u); err != BlockSizeError(8) {
+		t.Errorf("EncryptUUID with a 64-bit block error == %v, want BlockSizeError", err)
+	}
+	if _, err := DecryptUUID(small, u); err != BlockSizeError(8) {
+		t.Errorf("DecryptUUID with a 64-bit block error == %v, want BlockSizeError", err)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"crypto/cipher"
+)
+
+const xtsBlockSize = 16 			// XTS tweaks live in GF(2^128)
+
+// XTS is the IEEE 1619 sector encryption mode over two RC5-64 ciphers: one
+// for the data and one for the sector number tweak. Sectors need not be a
+// multiple of 16 bytes, the last partial block is handled by ciphertext
+// stealing, but must be at least 16 bytes.
+type XTS struct {
+	k1 				cipher.Block 	// data key
+	k2 				cipher.Block 	// tweak key
+}
+
+// NewXTS returns an XTS using RC5-64 with the given rounds under key1 for
+// data and key2 for tweaks. The keys must have the same length.
+func NewXTS(key1, key2 []byte, rounds uint) (*XTS, error) {
+	if len(key1) != len(key2) {
+		return nil, KeySizeError(len(key2))
+	}
+	k1, err := NewCipher64(key1, rounds)
+	if err != nil {
+		return nil, err
+	}
+	k2, err := NewCipher64(key2, rounds)
+	if err != nil {
+		return nil, err
+	}
+	return newXTS(k1, k2)
+}
+
+func newXTS(k1, k2 cipher.Block) (*XTS, error) {
+	if bs := k1.BlockSize(); bs != xtsBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	if bs := k2.BlockSize(); bs != xtsBlockSize {
+		return nil, BlockSizeError(bs)
+	}
+	return &XTS{k1, k2}, nil
+}
+
+// EncryptSector encrypts the sector src, numbered sectorNum, into dst,
+// which may be src.
+func (x *XTS) EncryptSector(dst, src []byte, sectorNum uint64) {
+	x.crypt(dst, src, sectorNum, true)
+}
+
+// DecryptSector decrypts the sector src, numbered sectorNum, into dst,
+// which may be src.
+func (x *XTS) DecryptSector(dst, src []byte, sectorNum uint64) {
+	x.crypt(dst, src, sectorNum, false)
+}
+
+func (x *XTS) crypt(dst, src []byte, sectorNum uint64, encrypt bool) {
+	if len(src) < xtsBlockSize {
+		panic("rc5: XTS sector smaller than one block")
+	}
+	if len(dst) < len(src) {
+		panic("rc5: output smaller than input")
+	}
+
+	// the tweak is the little-endian sector number, encrypted
+	tweak := make([]byte, xtsBlockSize)
+	for i := 0; i < 8; i++ {
+		tweak[i] = byte(sectorNum >> (8 * uint(i)))
+	}
+	x.k2.Encrypt(tweak, tweak)
+
+	tail := len(src) % xtsBlockSize
+	full := len(src) - tail
+	if tail > 0 {
+		// the last full block takes part in ciphertext stealing
+		full -= xtsBlockSize
+	}
+
+	for i := 0; i < full; i += xtsBlockSize {
+		x.cryptBlock(dst[i:i + xtsBlockSize], src[i:i + xtsBlockSize], tweak, encrypt)
+		mul2(tweak)
+	}
+	if tail == 0 {
+		return
+	}
+
+	last := src[full:]
+	next := make([]byte, xtsBlockSize)
+	copy(next, tweak)
+	mul2(next)
+
+	// decryption undoes the last two blocks with their tweaks swapped
+	first, second := tweak, next
+	if !encrypt {
+		first, second = next, tweak
+	}
+
+	cc := make([]byte, xtsBlockSize)
+	x.cryptBlock(cc, last[:xtsBlockSize], first, encrypt)
+
+	pp := make([]byte, xtsBlockSize)
+	copy(pp, last[xtsBlockSize:])
+	copy(pp[tail:], cc[tail:])
+
+	copy(dst[full + xtsBlockSize:len(src)], cc[:tail])
+	x.cryptBlock(dst[full:full + xtsBlockSize], pp, second, encrypt)
+}
+
+// cryptBlock is E(src ^ tweak) ^ tweak, or D in place of E.
+func (x *XTS) cryptBlock(dst, src, tweak []byte, encrypt bool) {
+	xorBytes(dst, src, tweak)
+	if encrypt {
+		x.k1.Encrypt(dst, dst)
+	} else {
+		x.k1.Decrypt(dst, dst)
+	}
+	xorBytes(dst, dst, tweak)
+}
+
+// mul2 multiplies the little-endian tweak by x in GF(2^128).
+func mul2(tweak []byte) {
+	var carry byte
+	for i := range tweak {
+		next := tweak[i] >> 7
+		tweak[i] = tweak[i] << 1 | carry
+		carry = next
+	}
+	if carry != 0 {
+		tweak[0] ^= 0x87
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 Marc Wilson, Scorpion Compute. All rights
+// reserved. Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file.
+
+package rc5
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// XTS-AES-128 vectors from IEEE 1619 appendix B, checking the mode itself.
+var xtsVectors = []struct {
+	key1 			string
+	key2 			string
+	sector 			uint64
+	plain 			string
+	cipher 			string
+}{
+	{"00000000000000000000000000000000", "00000000000000000000000000000000", 0,
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e"},
+	{"11111111111111111111111111111111", "22222222222222222222222222222222", 0x3333333333,
+		"4444444444444444444444444444444444444444444444444444444444444444",
+		"c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0"},
+
+	// vectors 15 to 18, data units of 17 to 20 bytes, for ciphertext stealing
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0", "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f10",
+		"6c1625db4671522d3d7599601de7ca09ed"},
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0", "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f1011",
+		"d069444b7a7e0cab09e24447d24deb1fedbf"},
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0", "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f101112",
+		"e5df1351c0544ba1350b3363cd8ef4beedbf9d"},
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0", "bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f10111213",
+		"9d84c813f719aa2c7be3f66171c7c5c2edbf9dac"},
+}
+
+func TestXTSVectors(t *testing.T) {
+	for n, v := range xtsVectors {
+		key1, _ := hex.DecodeString(v.key1)
+		key2, _ := hex.DecodeString(v.key2)
+		plain, _ := hex.DecodeString(v.plain)
+		expected, _ := hex.DecodeString(v.cipher)
+
+		k1, _ := aes.NewCipher(key1)
+		k2, _ := aes.NewCipher(key2)
+		x, _ := newXTS(k1, k2)
+
+		encrypted := make([]byte, len(plain))
+		x.EncryptSector(encrypted, plain, v.sector)
+		if !bytes.Equal(encrypted, expected) {
+			t.Errorf("vector %d: encrypt == % 02x, want % 02x", n + 1, encrypted, expected)
+		}
+		decrypted := make([]byte, len(expected))
+		x.DecryptSector(decrypted, expected, v.sector)
+		if !bytes.Equal(decrypted, plain) {
+			t.Errorf("vector %d: decrypt == % 02x, want % 02x", n + 1, decrypted, plain)
+		}
+	}
+}
+
+func TestXTS(t *testing.T) {
+	random := rand.New(rand.NewSource(99))
+	key1 := make([]byte, 16)
+	key2 := make([]byte, 16)
+	random.Read(key1)
+	random.Read(key2)
+
+	x, err := NewXTS(key1, key2, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for size := 16; size <= 80; size++ {
+		sector := random.Uint64()
+		value := make([]byte, size)
+		random.Read(value)
+
+		encrypted := make([]byte, size)
+		x.EncryptSector(encrypted, value, sector)
+
+		// blocks before the stolen pair are plain XTS
+		whole := make([]byte, size / 16 * 16)
+		x.EncryptSector(whole, value[:len(whole)], sector)
+		if keep := (size / 16 - 1) * 16; size % 16 != 0 && !bytes.Equal(encrypted[:keep], whole[:keep]) {
+			t.Errorf("EncryptSector(%d bytes) changed blocks before the stolen pair", size)
+		}
+
+		decrypted := make([]byte, size)
+		copy(decrypted, encrypted)
+		x.DecryptSector(decrypted, decrypted, sector)
+		if !bytes.Equal(decrypted, value) {
+			t.Errorf("DecryptSector(%d bytes) failed: % 02x != % 02x", size, decrypted, value)
+		}
+
+		x.DecryptSector(decrypted, encrypted, sector + 1)
+		if bytes.Equal(decrypted, value) {
+			t.Errorf("DecryptSector(%d bytes) ignored the sector number", size)
+		}
+	}
+}
+
+func TestXTSKeySize(t *testing.T) {
+	if _, err := NewXTS(make([]byte, 16), make([]byte, 24), 12); err != KeySizeError(24) {
+		t.Errorf("NewXTS error == %v, want %v", err, KeySizeError(24))
+	}
+}